@@ -0,0 +1,282 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"gorm.io/gorm"
+)
+
+// verifyBatchSize is how many entries are loaded at a time while walking a
+// chain.
+const verifyBatchSize = 1000
+
+// Break describes the first entry at which a company's chain stops verifying.
+type Break struct {
+	CompanyID uint   `json:"company_id"`
+	EntryID   uint   `json:"entry_id"`
+	Seq       uint64 `json:"seq"`
+	Reason    string `json:"reason"`
+}
+
+// Anchor is a chain head recorded outside the database. Verifying against
+// it detects entries removed from the end of the chain, which leave the
+// remaining chain intact.
+type Anchor struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Result is the outcome of verifying one company's chain. Head is the last
+// verified entry, to be recorded as the anchor for later verifications.
+type Result struct {
+	CompanyID uint    `json:"company_id"`
+	Checked   int     `json:"checked"`
+	Valid     bool    `json:"valid"`
+	Head      *Anchor `json:"head,omitempty"`
+	Break     *Break  `json:"first_broken,omitempty"`
+}
+
+// key is the HMAC key of the chain. It is kept out of the database so that
+// someone able to write the audit table cannot recompute valid hashes.
+var key []byte
+
+// InitKey loads the chain key from AUDIT_HMAC_KEY. Entries cannot be
+// written or verified without it.
+func InitKey() {
+	key = []byte(os.Getenv("AUDIT_HMAC_KEY"))
+	if len(key) == 0 {
+		log.Fatal("AUDIT_HMAC_KEY must be set")
+	}
+}
+
+// hashedFields is the canonical content covered by an entry's hash. Field
+// order is fixed by the struct and map keys are sorted by encoding/json.
+type hashedFields struct {
+	CompanyID  uint                   `json:"company_id"`
+	Seq        uint64                 `json:"seq"`
+	ActorID    uint                   `json:"actor_id"`
+	ActorRole  string                 `json:"actor_role"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   uint                   `json:"entity_id"`
+	Before     map[string]interface{} `json:"before"`
+	After      map[string]interface{} `json:"after"`
+	IP         string                 `json:"ip"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+// Hash computes the chain hash of an entry given the hash of its
+// predecessor, keyed with key.
+func Hash(key []byte, entry *models.AuditLog, prevHash string) (string, error) {
+	content, err := json.Marshal(hashedFields{
+		CompanyID:  entry.CompanyID,
+		Seq:        entry.Seq,
+		ActorID:    entry.ActorID,
+		ActorRole:  entry.ActorRole,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Before:     entry.Before,
+		After:      entry.After,
+		IP:         entry.IP,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(prevHash))
+	mac.Write(content)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Append adds an entry to the end of its company's chain using conn, which
+// may be an enclosing transaction. A transaction scoped advisory lock
+// serialises writers of the same company.
+func Append(conn *gorm.DB, entry *models.AuditLog) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", advisoryNamespace, int32(entry.CompanyID)).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditLog
+		err := tx.Where("company_id = ? AND hash <> ''", entry.CompanyID).Order("seq DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return err
+		}
+
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.Hash
+		// postgres keeps microseconds, so truncate before hashing
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+		if entry.Hash, err = Hash(key, entry, entry.PrevHash); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// advisoryNamespace keeps audit chain locks apart from other advisory locks.
+const advisoryNamespace = 36
+
+// walker checks entries of one chain in seq order.
+type walker struct {
+	key      []byte
+	anchor   *Anchor
+	prevHash string
+	prevSeq  uint64
+}
+
+// check returns why entry does not follow the entries checked before it, or
+// "" if it does.
+func (w *walker) check(entry *models.AuditLog) (string, error) {
+	if entry.Seq != w.prevSeq+1 {
+		return fmt.Sprintf("expected seq %d, found %d (entry missing or reordered)", w.prevSeq+1, entry.Seq), nil
+	}
+	if entry.PrevHash != w.prevHash {
+		return "prev_hash does not match the previous entry", nil
+	}
+	want, err := Hash(w.key, entry, w.prevHash)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(want), []byte(entry.Hash)) {
+		return "hash does not match entry content", nil
+	}
+	if w.anchor != nil && entry.Seq == w.anchor.Seq && entry.Hash != w.anchor.Hash {
+		return "hash does not match the anchored head", nil
+	}
+
+	w.prevHash = entry.Hash
+	w.prevSeq = entry.Seq
+	return "", nil
+}
+
+// end returns why the chain may not end after the entries checked, or "".
+func (w *walker) end() string {
+	if w.anchor != nil && w.prevSeq < w.anchor.Seq {
+		return fmt.Sprintf("chain ends at seq %d before the anchored head at seq %d (entries removed)", w.prevSeq, w.anchor.Seq)
+	}
+	return ""
+}
+
+// Verify walks a company's chain in seq order and reports the first entry
+// whose hash, link or sequence number does not match. With an anchor, the
+// chain must also reach the anchored head and agree with its hash.
+func Verify(companyID uint, anchor *Anchor) (Result, error) {
+	res := Result{CompanyID: companyID, Valid: true}
+	w := walker{key: key, anchor: anchor}
+
+	for {
+		// unsealed entries have seq 0 and are not part of the chain yet
+		var batch []models.AuditLog
+		err := database.DB.Where("company_id = ? AND seq > ?", companyID, w.prevSeq).
+			Order("seq").Limit(verifyBatchSize).Find(&batch).Error
+		if err != nil {
+			return res, err
+		}
+		if len(batch) == 0 {
+			if reason := w.end(); reason != "" {
+				res.Valid = false
+				res.Break = &Break{CompanyID: companyID, Seq: w.prevSeq + 1, Reason: reason}
+			}
+			return res, nil
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			reason, err := w.check(entry)
+			if err != nil {
+				return res, err
+			}
+			if reason != "" {
+				res.Valid = false
+				res.Break = &Break{CompanyID: companyID, EntryID: entry.ID, Seq: entry.Seq, Reason: reason}
+				return res, nil
+			}
+
+			res.Checked++
+			res.Head = &Anchor{Seq: entry.Seq, Hash: entry.Hash}
+		}
+	}
+}
+
+// VerifyAll verifies the chain of every company that has audit entries.
+func VerifyAll() ([]Result, error) {
+	var companyIDs []uint
+	if err := database.DB.Model(&models.AuditLog{}).Distinct().Order("company_id").Pluck("company_id", &companyIDs).Error; err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(companyIDs))
+	for _, id := range companyIDs {
+		res, err := Verify(id, nil)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// SealUnhashed links entries written before the chain existed into their
+// company's chain, in id order.
+func SealUnhashed() error {
+	var companyIDs []uint
+	if err := database.DB.Model(&models.AuditLog{}).Where("hash IS NULL OR hash = ''").
+		Distinct().Pluck("company_id", &companyIDs).Error; err != nil {
+		return err
+	}
+
+	for _, companyID := range companyIDs {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", advisoryNamespace, int32(companyID)).Error; err != nil {
+				return err
+			}
+
+			var entries []models.AuditLog
+			if err := tx.Where("company_id = ?", companyID).Order("id").Find(&entries).Error; err != nil {
+				return err
+			}
+
+			prevHash := ""
+			var seq uint64
+			for i := range entries {
+				entry := &entries[i]
+				seq++
+				if entry.Hash == "" {
+					entry.Seq = seq
+					entry.PrevHash = prevHash
+					entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+					hash, err := Hash(key, entry, prevHash)
+					if err != nil {
+						return err
+					}
+					entry.Hash = hash
+					if err := tx.Model(entry).Updates(map[string]interface{}{
+						"seq": entry.Seq, "prev_hash": entry.PrevHash, "hash": entry.Hash,
+					}).Error; err != nil {
+						return err
+					}
+				}
+				prevHash = entry.Hash
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+)
+
+var testKey = []byte("test-key")
+
+// buildChain returns n linked entries of company 1 hashed with k.
+func buildChain(t *testing.T, k []byte, n int) []models.AuditLog {
+	t.Helper()
+	entries := make([]models.AuditLog, n)
+	prev := ""
+	for i := range entries {
+		e := &entries[i]
+		e.ID = uint(i + 1)
+		e.CompanyID = 1
+		e.Seq = uint64(i + 1)
+		e.Action = models.AuditUpdate
+		e.EntityType = "item"
+		e.EntityID = 7
+		e.After = map[string]interface{}{"quantity": float64(i)}
+		e.CreatedAt = time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC)
+		e.PrevHash = prev
+		hash, err := Hash(k, e, prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Hash = hash
+		prev = hash
+	}
+	return entries
+}
+
+// walk runs the walker over entries and returns the seq and reason of the
+// first break, or 0 and "".
+func walk(t *testing.T, k []byte, anchor *Anchor, entries []models.AuditLog) (uint64, string) {
+	t.Helper()
+	w := walker{key: k, anchor: anchor}
+	for i := range entries {
+		reason, err := w.check(&entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason != "" {
+			return entries[i].Seq, reason
+		}
+	}
+	if reason := w.end(); reason != "" {
+		return w.prevSeq + 1, reason
+	}
+	return 0, ""
+}
+
+func TestHash(t *testing.T) {
+	entry := buildChain(t, testKey, 1)[0]
+
+	same, _ := Hash(testKey, &entry, "")
+	otherKey, _ := Hash([]byte("other"), &entry, "")
+	otherPrev, _ := Hash(testKey, &entry, "abc")
+
+	if same != entry.Hash {
+		t.Error("hash is not deterministic")
+	}
+	if len(same) != 64 {
+		t.Errorf("hash length = %d, want 64", len(same))
+	}
+	for name, h := range map[string]string{"other key": otherKey, "other prev hash": otherPrev} {
+		if h == same {
+			t.Errorf("%s gives the same hash", name)
+		}
+	}
+}
+
+func TestWalker(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		anchor  func(chain []models.AuditLog) *Anchor
+		tamper  func(chain []models.AuditLog) []models.AuditLog
+		wantSeq uint64
+		wantMsg string
+	}{
+		{name: "valid chain"},
+		{
+			name:    "edited content",
+			tamper:  func(c []models.AuditLog) []models.AuditLog { c[1].EntityID = 8; return c },
+			wantSeq: 2, wantMsg: "entry content",
+		},
+		{
+			name:    "missing entry",
+			tamper:  func(c []models.AuditLog) []models.AuditLog { return append(c[:1], c[2:]...) },
+			wantSeq: 3, wantMsg: "expected seq 2",
+		},
+		{
+			name:    "wrong key",
+			key:     []byte("other"),
+			wantSeq: 1, wantMsg: "entry content",
+		},
+		{
+			name: "rehashed with another key",
+			tamper: func(c []models.AuditLog) []models.AuditLog {
+				c[2].EntityID = 8
+				c[2].Hash, _ = Hash([]byte("guess"), &c[2], c[2].PrevHash)
+				return c
+			},
+			wantSeq: 3, wantMsg: "entry content",
+		},
+		{
+			name:   "anchored head present",
+			anchor: func(c []models.AuditLog) *Anchor { return &Anchor{Seq: 4, Hash: c[3].Hash} },
+		},
+		{
+			name:    "truncated after anchor",
+			anchor:  func(c []models.AuditLog) *Anchor { return &Anchor{Seq: 4, Hash: c[3].Hash} },
+			tamper:  func(c []models.AuditLog) []models.AuditLog { return c[:2] },
+			wantSeq: 3, wantMsg: "entries removed",
+		},
+		{
+			name:    "anchor hash differs",
+			anchor:  func(c []models.AuditLog) *Anchor { return &Anchor{Seq: 2, Hash: c[0].Hash} },
+			wantSeq: 2, wantMsg: "anchored head",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := buildChain(t, testKey, 4)
+			var anchor *Anchor
+			if tt.anchor != nil {
+				anchor = tt.anchor(chain)
+			}
+			if tt.tamper != nil {
+				chain = tt.tamper(chain)
+			}
+			k := testKey
+			if tt.key != nil {
+				k = tt.key
+			}
+
+			seq, reason := walk(t, k, anchor, chain)
+			if seq != tt.wantSeq || !strings.Contains(reason, tt.wantMsg) {
+				t.Errorf("break at seq %d (%q), want seq %d containing %q", seq, reason, tt.wantSeq, tt.wantMsg)
+			}
+		})
+	}
+}
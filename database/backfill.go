@@ -0,0 +1,22 @@
+package database
+
+import "log"
+
+// backfillCategoryPaths gives categories created before the hierarchy existed
+// a root-level materialized path.
+func backfillCategoryPaths() {
+	err := DB.Exec(`UPDATE categories SET path = '/' || id || '/' WHERE path IS NULL OR path = ''`).Error
+	if err != nil {
+		log.Println("⚠️  Could not backfill category paths:", err)
+	}
+}
+
+// backfillTransactionCompanies copies the company of each transaction's item
+// onto transactions recorded before they carried one.
+func backfillTransactionCompanies() {
+	err := DB.Exec(`UPDATE transactions SET company_id = items.company_id FROM items
+		WHERE transactions.item_id = items.id AND (transactions.company_id IS NULL OR transactions.company_id = 0)`).Error
+	if err != nil {
+		log.Println("⚠️  Could not backfill transaction companies:", err)
+	}
+}
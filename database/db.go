@@ -40,13 +40,41 @@ func InitDB() {
 	err = DB.AutoMigrate(
 		&models.User{},
 		&models.Category{},
+		&models.CategoryAttribute{},
+		&models.Tag{},
 		&models.Item{},
 		&models.Transaction{},
 		&models.PendingRequest{},
+		&models.ItemAttachment{},
+		&models.SKUSetting{},
+		&models.AuditLog{},
+		&models.ItemRevision{},
+		&models.IdempotencyKey{},
+		&models.ReturnAuthorization{},
+		&models.ReturnLine{},
+		&models.Backorder{},
+		&models.Notification{},
+		&models.ItemStock{},
+		&models.StockStatusMove{},
+		&models.ReasonCode{},
+		&models.WriteOff{},
+		&models.StockSnapshot{},
+		&models.StockSnapshotRun{},
+		&models.ReconciliationRun{},
+		&models.ReconciliationLine{},
 	)
 	if err != nil {
 		log.Fatal("Failed to auto-migrate models:", err)
 	}
 
+	createIndexes()
+	backfillCategoryPaths()
+	backfillTransactionCompanies()
+
+	// the ledger must be complete before stock reports read it
+	if err := runOnce("opening_balances", backfillOpeningBalances); err != nil {
+		log.Fatal("Failed to backfill opening balances:", err)
+	}
+
 	fmt.Println("Database migrated successfully.")
 }
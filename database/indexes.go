@@ -0,0 +1,55 @@
+package database
+
+import "log"
+
+// createIndexes adds indexes that AutoMigrate cannot express through struct
+// tags, such as the trigram index backing item text search.
+func createIndexes() {
+	stmts := []string{
+		`CREATE INDEX IF NOT EXISTS idx_items_attributes ON items USING gin (attributes jsonb_path_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_company_created ON items (company_id, created_at, id)`,
+		// one entry per position in each company's audit chain; unsealed
+		// entries written before the chain existed have seq 0
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_company_seq ON audit_logs (company_id, seq) WHERE seq > 0`,
+	}
+
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Println("⚠️  Could not enable pg_trgm, item search will not be indexed:", err)
+	} else {
+		stmts = append(stmts, `CREATE INDEX IF NOT EXISTS idx_items_search_trgm ON items USING gin (
+			(coalesce(name, '') || ' ' || coalesce(sku, '') || ' ' || coalesce(description, '')) gin_trgm_ops
+		)`)
+	}
+
+	for _, stmt := range stmts {
+		if err := DB.Exec(stmt).Error; err != nil {
+			log.Println("⚠️  Could not create index:", err)
+		}
+	}
+
+	createSKUIndex()
+}
+
+// createSKUIndex makes SKUs unique per company among live items; blank SKUs
+// from before generation existed are exempt. SKU lookups rely on it, so the
+// server refuses to start without it, after listing the duplicates that
+// have to be resolved first.
+func createSKUIndex() {
+	err := DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_items_company_sku_live
+		ON items (company_id, sku) WHERE sku <> '' AND deleted_at IS NULL`).Error
+	if err == nil {
+		return
+	}
+
+	var dups []struct {
+		CompanyID uint
+		SKU       string
+		Count     int
+	}
+	DB.Raw(`SELECT company_id, sku, COUNT(*) AS count FROM items
+		WHERE sku <> '' AND deleted_at IS NULL GROUP BY company_id, sku HAVING COUNT(*) > 1`).Scan(&dups)
+	for _, d := range dups {
+		log.Printf("⚠️  Company %d has %d items with SKU %q", d.CompanyID, d.Count, d.SKU)
+	}
+	log.Fatal("Could not create the unique SKU index, give the items listed above distinct SKUs: ", err)
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"gorm.io/gorm"
+)
+
+// schemaMigration marks a one-off data migration as applied.
+type schemaMigration struct {
+	Name      string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// runOnce runs a data migration in a transaction unless it has already been
+// applied. The advisory lock keeps two starting servers from both running
+// it.
+func runOnce(name string, fn func(tx *gorm.DB) error) error {
+	if err := DB.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "migration:"+name).Error; err != nil {
+			return err
+		}
+		var applied int64
+		if err := tx.Model(&schemaMigration{}).Where("name = ?", name).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			return nil
+		}
+
+		if err := fn(tx); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+		return tx.Create(&schemaMigration{Name: name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// backfillOpeningBalances posts an opening balance for items created before
+// every quantity change went through the ledger, so each item's
+// transactions sum to its quantity. The balance is whatever the existing
+// transactions do not account for, dated at the item's creation; existing
+// transactions are left as they are.
+func backfillOpeningBalances(tx *gorm.DB) error {
+	return tx.Exec(`INSERT INTO transactions
+			(item_id, company_id, quantity, type, status, unit_cost, note, user_id, reference_type, reference_id, created_at)
+		SELECT items.id, items.company_id, abs(items.quantity - ledger.net),
+			CASE WHEN items.quantity > ledger.net THEN ? ELSE ? END,
+			'', items.cost, ?, items.user_id, ?, items.id, items.created_at
+		FROM items
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(CASE WHEN t.type = ? THEN t.quantity ELSE -t.quantity END), 0) AS net
+			FROM transactions t WHERE t.item_id = items.id
+		) ledger
+		WHERE items.deleted_at IS NULL AND items.quantity <> ledger.net`,
+		models.TransactionIn, models.TransactionOut, "Opening balance",
+		models.ReferenceOpeningBalance, models.TransactionIn).Error
+}
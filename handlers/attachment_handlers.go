@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/Twinemukama/go-inventory-manager/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxUploadSize caps a single uploaded file.
+const maxUploadSize = 20 << 20
+
+// storageQuotaLockNamespace keeps storage quota locks apart from other
+// advisory locks.
+const storageQuotaLockNamespace = 30
+
+var errQuotaExceeded = errors.New("Company storage quota exceeded")
+
+var imageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// POST /items/:id/images
+func UploadItemImage(c *gin.Context) {
+	uploadItemFile(c, models.AttachmentImage)
+}
+
+// POST /items/:id/attachments
+func UploadItemAttachment(c *gin.Context) {
+	uploadItemFile(c, models.AttachmentDocument)
+}
+
+func uploadItemFile(c *gin.Context, kind string) {
+	id := c.Param("id")
+	var item models.Item
+
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if err := db(c).First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this item"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required in the 'file' field"})
+		return
+	}
+	if header.Size > maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Files may be at most %d MB", maxUploadSize>>20)})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	if kind == models.AttachmentImage && !imageContentTypes[contentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Images must be JPEG, PNG or GIF"})
+		return
+	}
+
+	key := attachmentKey(item.CompanyID, item.ID, filepath.Ext(header.Filename))
+	attachment := models.ItemAttachment{
+		ItemID:      item.ID,
+		CompanyID:   item.CompanyID,
+		UserID:      userID,
+		Kind:        kind,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+	}
+
+	// the quota check and the insert share a per-company lock so concurrent
+	// uploads cannot both fit under the quota and together exceed it
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", storageQuotaLockNamespace, int32(item.CompanyID)).Error; err != nil {
+			return err
+		}
+
+		var company models.Company
+		if err := tx.First(&company, item.CompanyID).Error; err != nil {
+			return err
+		}
+		var used int64
+		if err := tx.Model(&models.ItemAttachment{}).Where("company_id = ?", item.CompanyID).
+			Select("COALESCE(SUM(size), 0)").Scan(&used).Error; err != nil {
+			return err
+		}
+		if used+attachment.Size > company.StorageQuotaMB<<20 {
+			return errQuotaExceeded
+		}
+
+		size, err := storage.Files.Save(key, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("could not store file: %w", err)
+		}
+		attachment.Size = size
+
+		if kind == models.AttachmentImage {
+			if thumb, err := storage.Thumbnail(data); err == nil {
+				thumbKey := strings.TrimSuffix(key, filepath.Ext(key)) + "_thumb.jpg"
+				if _, err := storage.Files.Save(thumbKey, bytes.NewReader(thumb)); err == nil {
+					attachment.ThumbnailKey = thumbKey
+					attachment.HasThumbnail = true
+				}
+			}
+		}
+
+		return tx.Create(&attachment).Error
+	})
+	if err != nil {
+		removeAttachmentFiles(attachment)
+		if errors.Is(err, errQuotaExceeded) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, attachment.CompanyID, models.AuditUpload, entityAttachment, attachment.ID, nil, attachment) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GET /items/:id/attachments
+func ListItemAttachments(c *gin.Context) {
+	id := c.Param("id")
+	var item models.Item
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	query := db(c).Where("id = ?", id)
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	var attachments []models.ItemAttachment
+	attQuery := db(c).Where("item_id = ?", item.ID).Order("id")
+	if kind := c.Query("kind"); kind != "" {
+		attQuery = attQuery.Where("kind = ?", kind)
+	}
+	if err := attQuery.Find(&attachments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// GET /attachments/:id/download
+func DownloadAttachment(c *gin.Context) {
+	attachment, ok := loadAttachment(c)
+	if !ok {
+		return
+	}
+	serveStoredFile(c, attachment.StorageKey, attachment.ContentType, attachment.FileName)
+}
+
+// GET /attachments/:id/thumbnail
+func GetAttachmentThumbnail(c *gin.Context) {
+	attachment, ok := loadAttachment(c)
+	if !ok {
+		return
+	}
+	if !attachment.HasThumbnail {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment has no thumbnail"})
+		return
+	}
+	serveStoredFile(c, attachment.ThumbnailKey, "image/jpeg", "")
+}
+
+// DELETE /attachments/:id
+func DeleteAttachment(c *gin.Context) {
+	attachment, ok := loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	if role != "admin" && role != "super_admin" && attachment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to delete this attachment"})
+		return
+	}
+
+	if err := db(c).Delete(attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	removeAttachmentFiles(*attachment)
+
+	if !recordAudit(c, attachment.CompanyID, models.AuditDelete, entityAttachment, attachment.ID, attachment, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+func loadAttachment(c *gin.Context) (*models.ItemAttachment, bool) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var attachment models.ItemAttachment
+	query := db(c).Where("id = ?", c.Param("id"))
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return nil, false
+	}
+	return &attachment, true
+}
+
+func serveStoredFile(c *gin.Context, key, contentType, fileName string) {
+	f, err := storage.Files.Open(key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer f.Close()
+
+	headers := map[string]string{}
+	if fileName != "" {
+		headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=%q", fileName)
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, f, headers)
+}
+
+func removeAttachmentFiles(a models.ItemAttachment) {
+	storage.Files.Delete(a.StorageKey)
+	if a.ThumbnailKey != "" {
+		storage.Files.Delete(a.ThumbnailKey)
+	}
+}
+
+// attachmentKey builds a unique storage key for an item upload.
+func attachmentKey(companyID, itemID uint, ext string) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return fmt.Sprintf("companies/%d/items/%d/%s%s", companyID, itemID, hex.EncodeToString(buf), strings.ToLower(ext))
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+var (
+	errAttributeKeyTaken     = errors.New("Attribute key already defined for this category or a related one")
+	errAttributeNeedsDefault = errors.New("required attributes need a default when the category already has items")
+)
+
+// loadCategoryForAttributes finds the category named in the route, scoped to
+// the caller's company.
+func loadCategoryForAttributes(c *gin.Context) (*models.Category, bool) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var category models.Category
+	query := db(c).Where("id = ?", c.Param("id"))
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return nil, false
+	}
+	return &category, true
+}
+
+// GET /categories/:id/attributes
+func GetCategoryAttributes(c *gin.Context) {
+	category, ok := loadCategoryForAttributes(c)
+	if !ok {
+		return
+	}
+
+	attrs, err := categoryAttributes(db(c), category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attrs)
+}
+
+// POST /categories/:id/attributes
+func CreateCategoryAttribute(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can define category attributes"})
+		return
+	}
+
+	category, ok := loadCategoryForAttributes(c)
+	if !ok {
+		return
+	}
+
+	var attr models.CategoryAttribute
+	if err := c.ShouldBindJSON(&attr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !attributeKeyPattern.MatchString(attr.Key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key must be lowercase letters, digits or underscores and start with a letter"})
+		return
+	}
+
+	switch attr.Type {
+	case models.AttributeText, models.AttributeNumber, models.AttributeDate:
+		attr.Options = nil
+	case models.AttributeEnum:
+		if len(attr.Options) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "enum attributes need at least one option"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of text, number, date, enum"})
+		return
+	}
+
+	if attr.Default != nil {
+		v, err := normalizeAttribute(attr, attr.Default)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "default: " + err.Error()})
+			return
+		}
+		attr.Default = v
+	}
+
+	attr.ID = 0
+	attr.CategoryID = category.ID
+	if attr.Label == "" {
+		attr.Label = attr.Key
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		subtree, err := subtreeCategoryIDs(tx, category)
+		if err != nil {
+			return err
+		}
+
+		// keys must be unique across the category, its ancestors and its
+		// descendants, since items inherit attributes from parent categories
+		existing, err := categoryAttributes(tx, category)
+		if err != nil {
+			return err
+		}
+		for _, a := range existing {
+			if a.Key == attr.Key {
+				return errAttributeKeyTaken
+			}
+		}
+		added := []models.CategoryAttribute{attr}
+		if err := checkInheritedAttributes(tx, subtree, added); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&attr).Error; err != nil {
+			return err
+		}
+		return applyAttributeDefaults(tx, subtree, added)
+	})
+	if errors.Is(err, errAttributeKeyTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errAttributeNeedsDefault) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, category.CompanyID, models.AuditCreate, entityCategoryAttribute, attr.ID, nil, attr) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, attr)
+}
+
+// DELETE /categories/:id/attributes/:attrId
+func DeleteCategoryAttribute(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can delete category attributes"})
+		return
+	}
+
+	category, ok := loadCategoryForAttributes(c)
+	if !ok {
+		return
+	}
+
+	var attr models.CategoryAttribute
+	if err := db(c).First(&attr, "id = ? AND category_id = ?", c.Param("attrId"), category.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+		return
+	}
+
+	// items of the category and its subcategories drop the value along with
+	// the definition, or they would fail validation as an unknown attribute
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attr).Error; err != nil {
+			return err
+		}
+		subtree, err := subtreeCategoryIDs(tx, category)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Item{}).Unscoped().
+			Where("category_id IN ? AND attributes -> ? IS NOT NULL", subtree, attr.Key).
+			Updates(map[string]interface{}{
+				"attributes": gorm.Expr("attributes - ?::text", attr.Key),
+				"version":    gorm.Expr("version + 1"),
+			}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, category.CompanyID, models.AuditDelete, entityCategoryAttribute, attr.ID, attr, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attribute deleted"})
+}
+
+// categoryAttributes returns the attributes declared on a category and on
+// all of its ancestors.
+func categoryAttributes(conn *gorm.DB, category *models.Category) ([]models.CategoryAttribute, error) {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(category.Path, "/"), "/") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) == 0 {
+		ids = []uint{category.ID}
+	}
+
+	var attrs []models.CategoryAttribute
+	err := conn.Where("category_id IN ?", ids).Order("id").Find(&attrs).Error
+	return attrs, err
+}
+
+// subtreeCategoryIDs returns the ids of a category and all of its
+// descendants, trashed ones included.
+func subtreeCategoryIDs(conn *gorm.DB, category *models.Category) ([]uint, error) {
+	if category.Path == "" {
+		return []uint{category.ID}, nil
+	}
+	var ids []uint
+	err := conn.Model(&models.Category{}).Unscoped().
+		Where("company_id = ? AND path LIKE ?", category.CompanyID, category.Path+"%").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// checkInheritedAttributes reports whether the categories in subtree can take
+// on attrs from above: none of their keys may be declared in the subtree
+// already, and required attributes without a default may only be added where
+// there are no items yet.
+func checkInheritedAttributes(conn *gorm.DB, subtree []uint, attrs []models.CategoryAttribute) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	var below []models.CategoryAttribute
+	if err := conn.Where("category_id IN ?", subtree).Find(&below).Error; err != nil {
+		return err
+	}
+	keys := make(map[string]bool, len(below))
+	for _, a := range below {
+		keys[a.Key] = true
+	}
+
+	needsItemsCheck := false
+	for _, a := range attrs {
+		if keys[a.Key] {
+			return errAttributeKeyTaken
+		}
+		if a.Required && a.Default == nil {
+			needsItemsCheck = true
+		}
+	}
+	if !needsItemsCheck {
+		return nil
+	}
+
+	var count int64
+	if err := conn.Model(&models.Item{}).Unscoped().Where("category_id IN ?", subtree).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errAttributeNeedsDefault
+	}
+	return nil
+}
+
+// applyAttributeDefaults gives items in subtree the defaults of attrs they do
+// not have yet, so later edits of those items still validate.
+func applyAttributeDefaults(conn *gorm.DB, subtree []uint, attrs []models.CategoryAttribute) error {
+	for _, a := range attrs {
+		if a.Default == nil {
+			continue
+		}
+		err := conn.Model(&models.Item{}).Unscoped().
+			Where("category_id IN ? AND (attributes IS NULL OR attributes -> ? IS NULL)", subtree, a.Key).
+			Updates(map[string]interface{}{
+				"attributes": gorm.Expr("COALESCE(attributes, '{}'::jsonb) || jsonb_build_object(?::text, ?::jsonb)", a.Key, jsonValue(a.Default)),
+				"version":    gorm.Expr("version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonValue encodes an attribute value for a jsonb parameter.
+func jsonValue(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// validateItemAttributes checks an item's custom attributes against the
+// definitions of its category and returns them in normalized form.
+func validateItemAttributes(conn *gorm.DB, companyID, categoryID uint, values map[string]interface{}) (map[string]interface{}, error) {
+	if categoryID == 0 {
+		if len(values) > 0 {
+			return nil, fmt.Errorf("attributes require a category")
+		}
+		return nil, nil
+	}
+
+	var category models.Category
+	if err := conn.First(&category, "id = ? AND company_id = ?", categoryID, companyID).Error; err != nil {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	defs, err := categoryAttributes(conn, &category)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(defs))
+	result := make(map[string]interface{}, len(values))
+	for _, def := range defs {
+		known[def.Key] = true
+
+		raw, present := values[def.Key]
+		if (!present || raw == nil || raw == "") && def.Default != nil {
+			raw, present = def.Default, true
+		}
+		if !present || raw == nil || raw == "" {
+			if def.Required {
+				return nil, fmt.Errorf("attribute %q is required", def.Key)
+			}
+			continue
+		}
+
+		v, err := normalizeAttribute(def, raw)
+		if err != nil {
+			return nil, err
+		}
+		result[def.Key] = v
+	}
+
+	for key := range values {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown attribute %q for this category", key)
+		}
+	}
+
+	return result, nil
+}
+
+func normalizeAttribute(def models.CategoryAttribute, raw interface{}) (interface{}, error) {
+	switch def.Type {
+	case models.AttributeNumber:
+		switch v := raw.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				return n, nil
+			}
+		}
+		return nil, fmt.Errorf("attribute %q must be a number", def.Key)
+	case models.AttributeDate:
+		if s, ok := raw.(string); ok {
+			if t, err := time.Parse("2006-01-02", s); err == nil {
+				return t.Format("2006-01-02"), nil
+			}
+		}
+		return nil, fmt.Errorf("attribute %q must be a date (YYYY-MM-DD)", def.Key)
+	case models.AttributeEnum:
+		if s, ok := raw.(string); ok {
+			for _, opt := range def.Options {
+				if s == opt {
+					return s, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("attribute %q must be one of %s", def.Key, strings.Join(def.Options, ", "))
+	default:
+		if s, ok := raw.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("attribute %q must be text", def.Key)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Twinemukama/go-inventory-manager/audit"
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Entity types recorded in the audit log.
+const (
+	entityItem              = "item"
+	entityCategory          = "category"
+	entityCategoryAttribute = "category_attribute"
+	entityTag               = "tag"
+	entityAttachment        = "attachment"
+	entitySKUSetting        = "sku_setting"
+	entityUser              = "user"
+	entityPendingRequest    = "pending_request"
+)
+
+// auditSnapshotSkip lists preloaded associations left out of snapshots; they
+// are recorded as entities of their own.
+var auditSnapshotSkip = []string{"user", "company", "User", "Company", "Items", "items", "Users", "children"}
+
+// recordAudit appends an audit entry for a change made by the current caller
+// to the company's hash chain.
+// Outside a transaction the change itself has already been committed, so a
+// failure is only logged. Inside one, such as an atomic batch, the change
+// must not commit without its entry: recordAudit responds with an error and
+// returns false, and the caller must stop.
+func recordAudit(c *gin.Context, companyID uint, action, entityType string, entityID uint, before, after interface{}) bool {
+	entry := models.AuditLog{
+		CompanyID:  companyID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		IP:         c.ClientIP(),
+	}
+	if v, ok := c.Get("userId"); ok {
+		entry.ActorID = v.(uint)
+	}
+	if v, ok := c.Get("role"); ok {
+		entry.ActorRole = v.(string)
+	}
+
+	if err := audit.Append(db(c), &entry); err != nil {
+		log.Printf("Failed to record audit entry for %s %s %d: %v", action, entityType, entityID, err)
+		if database.InTx(c.Request.Context()) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not record audit entry"})
+			return false
+		}
+	}
+	return true
+}
+
+// auditSnapshot converts a model into the JSON object stored in the log.
+func auditSnapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	for _, k := range auditSnapshotSkip {
+		delete(m, k)
+	}
+	return m
+}
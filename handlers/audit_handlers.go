@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Twinemukama/go-inventory-manager/audit"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+)
+
+// auditSortColumns whitelists the columns GetAuditLogs may order by.
+var auditSortColumns = map[string]keysetColumn{
+	"created_at": {"audit_logs.created_at", keysetTime},
+}
+
+// GET /audit
+func GetAuditLogs(c *gin.Context) {
+	pg := parsePageParams(c)
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view the audit log"})
+		return
+	}
+
+	var logs []models.AuditLog
+	var total int64
+
+	query := db(c).Model(&models.AuditLog{})
+
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	} else if v := c.Query("company_id"); v != "" {
+		query = query.Where("company_id = ?", v)
+	}
+
+	filters := []struct{ param, clause string }{
+		{"actor_id", "actor_id = ?"},
+		{"action", "action = ?"},
+		{"entity_type", "entity_type = ?"},
+		{"entity_id", "entity_id = ?"},
+	}
+	for _, f := range filters {
+		if v := c.Query(f.param); v != "" {
+			query = query.Where(f.clause, v)
+		}
+	}
+
+	dateFilters := []struct{ param, clause string }{
+		{"from", "created_at >= ?"},
+		{"to", "created_at <= ?"},
+	}
+	for _, f := range dateFilters {
+		if v := c.Query(f.param); v != "" {
+			t, err := parseDateParam(v, f.param == "to")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + f.param + ", expected YYYY-MM-DD or RFC3339"})
+				return
+			}
+			query = query.Where(f.clause, t)
+		}
+	}
+
+	// newest first unless the caller asks otherwise
+	sort, err := parseSort(c.Query("sort"), auditSortColumns, "audit_logs.id", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if pg.Keyset {
+		if pg.Cursor != "" {
+			if query, err = sort.after(query, pg.Cursor); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := query.Order(sort.orderClause()).Limit(pg.Limit + 1).Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var nextCursor string
+		if len(logs) > pg.Limit {
+			logs = logs[:pg.Limit]
+			last := logs[len(logs)-1]
+			nextCursor = sort.cursorFor(last.CreatedAt, last.ID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"audit_logs":  logs,
+			"limit":       pg.Limit,
+			"next_cursor": nextCursor,
+		})
+		return
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Order(sort.orderClause()).Limit(pg.Limit).Offset(pg.offset()).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audit_logs": logs,
+		"page":       pg.Page,
+		"limit":      pg.Limit,
+		"total":      total,
+	})
+}
+
+// GET /audit/verify
+//
+// Pass anchor_seq and anchor_hash from the head of an earlier verification,
+// recorded outside the database, to also detect entries removed from the
+// end of the chain.
+func VerifyAuditChain(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can verify the audit log"})
+		return
+	}
+
+	// super admins verify every company unless they pick one
+	if role == "super_admin" && c.Query("company_id") == "" {
+		results, err := audit.VerifyAll()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		valid := true
+		for _, r := range results {
+			valid = valid && r.Valid
+		}
+		c.JSON(http.StatusOK, gin.H{"valid": valid, "companies": results})
+		return
+	}
+
+	if role == "super_admin" {
+		id, err := strconv.ParseUint(c.Query("company_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid company_id"})
+			return
+		}
+		companyID = uint(id)
+	}
+
+	var anchor *audit.Anchor
+	if c.Query("anchor_seq") != "" {
+		seq, err := strconv.ParseUint(c.Query("anchor_seq"), 10, 64)
+		if err != nil || seq == 0 || c.Query("anchor_hash") == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "anchor_seq must be a positive integer and anchor_hash is required with it"})
+			return
+		}
+		anchor = &audit.Anchor{Seq: seq, Hash: c.Query("anchor_hash")}
+	}
+
+	result, err := audit.Verify(companyID, anchor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
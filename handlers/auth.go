@@ -11,7 +11,6 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 
-	"github.com/Twinemukama/go-inventory-manager/database"
 	"github.com/Twinemukama/go-inventory-manager/models"
 )
 
@@ -93,7 +92,7 @@ func Signup(c *gin.Context) {
 	}
 
 	var existing models.User
-	if err := database.DB.Where("email = ?", creds.Email).First(&existing).Error; err == nil {
+	if err := db(c).Where("email = ?", creds.Email).First(&existing).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 		return
 	}
@@ -108,7 +107,7 @@ func Signup(c *gin.Context) {
 
 	if creds.CompanyID != 0 {
 		var company models.Company
-		if err := database.DB.First(&company, creds.CompanyID).Error; err != nil {
+		if err := db(c).First(&company, creds.CompanyID).Error; err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Selected company does not exist"})
 			return
 		}
@@ -122,7 +121,7 @@ func Signup(c *gin.Context) {
 			Verified:  false,
 		}
 
-		if err := database.DB.Create(&user).Error; err != nil {
+		if err := db(c).Create(&user).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
 			return
 		}
@@ -138,11 +137,15 @@ func Signup(c *gin.Context) {
 			Note:        "New user signup request for company approval",
 			RequestedAt: time.Now(),
 		}
-		if err := database.DB.Create(&pending).Error; err != nil {
+		if err := db(c).Create(&pending).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create pending request"})
 			return
 		}
 
+		if !recordAudit(c, company.ID, models.AuditSignup, entityUser, user.ID, nil, user) {
+			return
+		}
+
 		c.JSON(http.StatusCreated, gin.H{
 			"message": "Signup successful. Waiting for company admin approval.",
 			"status":  "pending_approval",
@@ -158,14 +161,14 @@ func Signup(c *gin.Context) {
 
 	// enforce uniqueness for new company creation at application level
 	var existingCompany models.Company
-	if err := database.DB.Where("name = ?", companyName).First(&existingCompany).Error; err == nil {
+	if err := db(c).Where("name = ?", companyName).First(&existingCompany).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Company name already exists"})
 		return
 	}
 
 	company := models.Company{Name: companyName}
 
-	if err := database.DB.Create(&company).Error; err != nil {
+	if err := db(c).Create(&company).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create company"})
 		return
 	}
@@ -180,11 +183,15 @@ func Signup(c *gin.Context) {
 		Verified:  true, // admins are always verified
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := db(c).Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
 		return
 	}
 
+	if !recordAudit(c, company.ID, models.AuditSignup, entityUser, user.ID, nil, user) {
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Company created successfully. You are now the admin.",
 		"status":  "approved",
@@ -201,7 +208,7 @@ func Login(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := database.DB.Where("email = ?", creds.Email).First(&user).Error; err != nil {
+	if err := db(c).Where("email = ?", creds.Email).First(&user).Error; err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -262,7 +269,7 @@ func VerifyUser(c *gin.Context) {
 	// Parse target user
 	id := c.Param("id")
 	var user models.User
-	if err := database.DB.First(&user, id).Error; err != nil {
+	if err := db(c).First(&user, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -274,12 +281,17 @@ func VerifyUser(c *gin.Context) {
 	}
 
 	// Mark as verified
+	before := user
 	user.Verified = true
-	if err := database.DB.Save(&user).Error; err != nil {
+	if err := db(c).Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update user"})
 		return
 	}
 
+	if !recordAudit(c, user.CompanyID, models.AuditVerify, entityUser, user.ID, before, user) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User verified successfully", "user": user})
 }
 
@@ -298,7 +310,7 @@ func RejectUser(c *gin.Context) {
 	var user models.User
 
 	// Find user
-	if err := database.DB.First(&user, id).Error; err != nil {
+	if err := db(c).First(&user, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -310,11 +322,15 @@ func RejectUser(c *gin.Context) {
 	}
 
 	// Delete the user
-	if err := database.DB.Delete(&user).Error; err != nil {
+	if err := db(c).Delete(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reject user"})
 		return
 	}
 
+	if !recordAudit(c, user.CompanyID, models.AuditReject, entityUser, user.ID, user, nil) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User rejected successfully"})
 }
 
@@ -331,7 +347,7 @@ func GetPendingUsers(c *gin.Context) {
 
 	var users []models.User
 
-	query := database.DB.Where("verified = ?", false)
+	query := db(c).Where("verified = ?", false)
 
 	// Restrict to same company if not super_admin
 	if role != "super_admin" {
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const entityBackorder = "backorder"
+
+// POST /backorders
+func CreateBackorder(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	companyID := c.MustGet("companyId").(uint)
+
+	var body struct {
+		ItemID         uint   `json:"item_id" binding:"required"`
+		Quantity       int    `json:"quantity" binding:"required"`
+		OrderReference string `json:"order_reference"`
+		Priority       int    `json:"priority"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be positive"})
+		return
+	}
+
+	var item models.Item
+	if err := db(c).First(&item, "id = ? AND company_id = ?", body.ItemID, companyID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Item not found"})
+		return
+	}
+
+	backorder := models.Backorder{
+		CompanyID:      companyID,
+		ItemID:         item.ID,
+		OrderReference: body.OrderReference,
+		Quantity:       body.Quantity,
+		Priority:       body.Priority,
+		Status:         models.BackorderOpen,
+		UserID:         userID,
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		// item before backorders, the order postStockMovement locks them in
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Item{}, item.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&backorder).Error; err != nil {
+			return err
+		}
+		// stock may already be on hand
+		return allocateBackorders(tx, companyID, item.ID, userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	db(c).First(&backorder, backorder.ID)
+	if !recordAudit(c, companyID, models.AuditCreate, entityBackorder, backorder.ID, nil, backorder) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, backorder)
+}
+
+// GET /backorders
+func ListBackorders(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var backorders []models.Backorder
+
+	query := db(c).Order("priority DESC, created_at, id")
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if v := c.Query("status"); v != "" {
+		query = query.Where("status = ?", v)
+	}
+	if v := c.Query("item_id"); v != "" {
+		query = query.Where("item_id = ?", v)
+	}
+
+	if err := query.Find(&backorders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, backorders)
+}
+
+// POST /backorders/:id/cancel
+func CancelBackorder(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var backorder models.Backorder
+	if err := db(c).First(&backorder, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Backorder not found"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && backorder.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot cancel this backorder"})
+		return
+	}
+	if backorder.Status != models.BackorderOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Backorder is " + backorder.Status})
+		return
+	}
+
+	// stock already allocated stays with the order; only the remainder is cancelled
+	before := backorder
+	backorder.Status = models.BackorderCancelled
+	if err := db(c).Model(&backorder).Update("status", backorder.Status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, companyID, models.AuditUpdate, entityBackorder, backorder.ID, before, backorder) {
+		return
+	}
+
+	c.JSON(http.StatusOK, backorder)
+}
+
+// allocateBackorders hands the item's available stock to its open
+// backorders, highest priority first and oldest first within a priority.
+// Each allocation leaves stock with an OUT transaction and notifies the
+// backorder's owner. Every path that adds available stock calls it.
+func allocateBackorders(tx *gorm.DB, companyID, itemID, userID uint) error {
+	// lock the item before its backorders, like postStockMovement, so
+	// concurrent allocations cannot deadlock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Item{}, itemID).Error; err != nil {
+		return err
+	}
+
+	var backorders []models.Backorder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND item_id = ? AND status = ?", companyID, itemID, models.BackorderOpen).
+		Order("priority DESC, created_at, id").Find(&backorders).Error; err != nil {
+		return err
+	}
+
+	for _, b := range backorders {
+		var item models.Item
+		if err := tx.First(&item, itemID).Error; err != nil {
+			return err
+		}
+		available, err := stockInStatus(tx, item, models.StockAvailable)
+		if err != nil {
+			return err
+		}
+		if available <= 0 {
+			return nil
+		}
+
+		take := b.Remaining()
+		if take > available {
+			take = available
+		}
+
+		if _, err := postStockMovement(tx, stockMovement{
+			ItemID:        itemID,
+			CompanyID:     companyID,
+			Type:          models.TransactionOut,
+			Quantity:      take,
+			Note:          fmt.Sprintf("Allocated to backorder #%d", b.ID),
+			UserID:        userID,
+			ReferenceType: entityBackorder,
+			ReferenceID:   b.ID,
+		}); err != nil {
+			return err
+		}
+
+		b.Allocated += take
+		updates := map[string]interface{}{"allocated": b.Allocated}
+		msg := fmt.Sprintf("%d of %s allocated to backorder #%d; %d still waiting", take, item.Name, b.ID, b.Remaining())
+		if b.Remaining() == 0 {
+			now := time.Now()
+			updates["status"] = models.BackorderFilled
+			updates["filled_at"] = &now
+			msg = fmt.Sprintf("Backorder #%d for %d of %s is now fully allocated", b.ID, b.Quantity, item.Name)
+		}
+		if err := tx.Model(&b).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.Notification{
+			UserID:     b.UserID,
+			CompanyID:  companyID,
+			Type:       "backorder_allocated",
+			Message:    msg,
+			EntityType: entityBackorder,
+			EntityID:   b.ID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
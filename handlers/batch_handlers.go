@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBatchOperations caps the number of sub-requests in one batch.
+const maxBatchOperations = 100
+
+// batchForwardHeaders are the sub-request headers a batch operation may set.
+var batchForwardHeaders = []string{"If-Match", "Content-Type"}
+
+type batchOperation struct {
+	ID      string            `json:"id"` // optional client reference echoed in the result
+	Method  string            `json:"method" binding:"required"`
+	Path    string            `json:"path" binding:"required"`
+	Body    json.RawMessage   `json:"body"`
+	Headers map[string]string `json:"headers"`
+}
+
+type batchResult struct {
+	ID     string      `json:"id,omitempty"`
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Status int         `json:"status"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+var errBatchAborted = errors.New("batch aborted")
+
+// batchContextKey marks the context of sub-requests dispatched by a batch.
+type batchContextKey struct{}
+
+// Batch returns the POST /batch handler. Each sub-request is dispatched
+// through router, in order, with the caller's Authorization header. With
+// "atomic": true every sub-request shares one database transaction and the
+// batch stops and rolls back at the first failed operation.
+func Batch(router http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the path check below should already stop nesting, this is the
+		// backstop for paths it does not foresee
+		if c.Request.Context().Value(batchContextKey{}) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Batches cannot be nested"})
+			return
+		}
+
+		var body struct {
+			Atomic   bool             `json:"atomic"`
+			Requests []batchOperation `json:"requests" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(body.Requests) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "requests must not be empty"})
+			return
+		}
+		if len(body.Requests) > maxBatchOperations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many requests; a batch may hold at most 100"})
+			return
+		}
+		for i, op := range body.Requests {
+			body.Requests[i].Method = strings.ToUpper(op.Method)
+			if !validBatchPath(op.Path) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid path in batch: " + op.Path})
+				return
+			}
+		}
+
+		results := make([]batchResult, 0, len(body.Requests))
+
+		if !body.Atomic {
+			for _, op := range body.Requests {
+				results = append(results, runBatchOperation(c, router, op, nil))
+			}
+			c.JSON(http.StatusOK, gin.H{"atomic": false, "results": results})
+			return
+		}
+
+		err := db(c).Transaction(func(tx *gorm.DB) error {
+			for _, op := range body.Requests {
+				res := runBatchOperation(c, router, op, tx)
+				results = append(results, res)
+				if res.Status >= http.StatusBadRequest {
+					return errBatchAborted
+				}
+			}
+			return nil
+		})
+
+		committed := err == nil
+		if err != nil && !errors.Is(err, errBatchAborted) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// report the operations that never ran after a failure
+		for _, op := range body.Requests[len(results):] {
+			results = append(results, batchResult{
+				ID: op.ID, Method: op.Method, Path: op.Path,
+				Status: http.StatusFailedDependency,
+				Body:   gin.H{"error": "Not executed because an earlier operation failed"},
+			})
+		}
+
+		status := http.StatusOK
+		if !committed {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"atomic": true, "committed": committed, "results": results})
+	}
+}
+
+// validBatchPath reports whether a sub-request may target p. The check runs
+// on the path as the router will see it, since escapes such as "/%62atch"
+// or a leading "//host" would otherwise slip past a check on the raw string.
+func validBatchPath(p string) bool {
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+	u, err := url.Parse(p)
+	if err != nil || u.Host != "" {
+		return false
+	}
+	clean := path.Clean(u.Path)
+	return clean != "/batch" && !strings.HasPrefix(clean, "/batch/")
+}
+
+// runBatchOperation dispatches one sub-request and captures its response.
+// When tx is set the sub-request's handlers run their queries on it.
+func runBatchOperation(c *gin.Context, router http.Handler, op batchOperation, tx *gorm.DB) batchResult {
+	res := batchResult{ID: op.ID, Method: op.Method, Path: op.Path}
+
+	var reqBody []byte
+	if len(op.Body) > 0 && !isJSONNull(op.Body) {
+		reqBody = op.Body
+	}
+
+	ctx := context.WithValue(c.Request.Context(), batchContextKey{}, true)
+	if tx != nil {
+		ctx = database.WithTx(ctx, tx)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.Method, op.Path, bytes.NewReader(reqBody))
+	if err != nil {
+		res.Status = http.StatusBadRequest
+		res.Body = gin.H{"error": err.Error()}
+		return res
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+	req.Header.Set("Authorization", c.GetHeader("Authorization"))
+	for _, h := range []string{"X-Forwarded-For", "X-Real-IP"} {
+		if v := c.GetHeader(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, h := range batchForwardHeaders {
+		if v, ok := op.Headers[h]; ok {
+			req.Header.Set(h, v)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	res.Status = rec.Code
+	if rec.Body.Len() > 0 {
+		var decoded interface{}
+		if json.Unmarshal(rec.Body.Bytes(), &decoded) == nil {
+			res.Body = decoded
+		} else {
+			res.Body = rec.Body.String()
+		}
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidBatchPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/items", true},
+		{"/items/4?fields=name", true},
+		{"/batches", true},
+		{"items", false},
+		{"/batch", false},
+		{"/batch/", false},
+		{"/%62atch", false},
+		{"//evil/batch", false},
+		{"/./batch", false},
+		{"/items/../batch", false},
+		{"/batch?x=1", false},
+		{"/%zz", false},
+	}
+	for _, tt := range tests {
+		if got := validBatchPath(tt.path); got != tt.want {
+			t.Errorf("validBatchPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBatchRefusesNesting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/batch", Batch(r))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		body string
+	}{
+		{name: "escaped path", ctx: context.Background(), body: `{"requests":[{"method":"POST","path":"/%62atch"}]}`},
+		{name: "sub-request context", ctx: context.WithValue(context.Background(), batchContextKey{}, true), body: `{"requests":[{"method":"GET","path":"/items"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(tt.body)).WithContext(tt.ctx)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBulkItems caps how many items a single bulk request may touch.
+const maxBulkItems = 1000
+
+const (
+	bulkSetCategory = "set_category"
+	bulkAdjustPrice = "adjust_price"
+	bulkAddTag      = "add_tag"
+	bulkDelete      = "delete"
+)
+
+type bulkItemRequest struct {
+	IDs []uint `json:"ids"`
+	// AllMatching applies the operation to every item matching the GET /items
+	// filters given in the query string, instead of IDs.
+	AllMatching bool    `json:"all_matching"`
+	Operation   string  `json:"operation" binding:"required"`
+	CategoryID  *uint   `json:"category_id"`
+	Percent     float64 `json:"percent"`
+	TagID       uint    `json:"tag_id"`
+	// Partial commits the items that succeeded even when others fail. By
+	// default the whole request is rolled back on any failure.
+	Partial bool `json:"partial"`
+}
+
+type bulkItemResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"` // ok, not_found, forbidden, failed or rolled_back
+	Error  string `json:"error,omitempty"`
+}
+
+var errBulkFailed = errors.New("bulk operation failed")
+
+// POST /items/bulk
+func BulkItems(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var body bulkItemRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate the operation arguments once, up front
+	var category *models.Category
+	var tag *models.Tag
+	switch body.Operation {
+	case bulkSetCategory:
+		if body.CategoryID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required (0 to uncategorize)"})
+			return
+		}
+		if *body.CategoryID != 0 {
+			category = &models.Category{}
+			if err := db(c).First(category, "id = ? AND company_id = ?", *body.CategoryID, companyID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+				return
+			}
+		}
+	case bulkAdjustPrice:
+		if body.Percent <= -100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "percent must be greater than -100"})
+			return
+		}
+	case bulkAddTag:
+		tag = &models.Tag{}
+		if err := db(c).First(tag, "id = ? AND company_id = ?", body.TagID, companyID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tag not found"})
+			return
+		}
+	case bulkDelete:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operation must be one of set_category, adjust_price, add_tag, delete"})
+		return
+	}
+
+	// resolve the target ids
+	ids := uniqueUints(body.IDs)
+	if body.AllMatching {
+		if len(ids) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Use either ids or all_matching, not both"})
+			return
+		}
+		query, err := applyItemFilters(c, db(c).Model(&models.Item{}).Where("company_id = ?", companyID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := query.Order("id").Limit(maxBulkItems+1).Pluck("id", &ids).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No items selected"})
+		return
+	}
+	if len(ids) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many items; bulk requests are limited to 1000 items"})
+		return
+	}
+
+	results := make([]bulkItemResult, 0, len(ids))
+	type auditChange struct{ before, after models.Item }
+	var changes []auditChange
+	failed := 0
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		var items []models.Item
+		if err := tx.Where("id IN ? AND company_id = ?", ids, companyID).Find(&items).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]models.Item, len(items))
+		for _, item := range items {
+			byID[item.ID] = item
+		}
+
+		for _, id := range ids {
+			item, ok := byID[id]
+			if !ok {
+				results = append(results, bulkItemResult{ID: id, Status: "not_found"})
+				failed++
+				continue
+			}
+			// same ownership rule as UpdateItem
+			if role != "admin" && role != "super_admin" && item.UserID != userID {
+				results = append(results, bulkItemResult{ID: id, Status: "forbidden"})
+				failed++
+				continue
+			}
+
+			before := item
+			// a savepoint per item lets partial requests keep the successes
+			err := tx.Transaction(func(itx *gorm.DB) error {
+				return applyBulkOperation(itx, &item, before, body, category, tag, userID)
+			})
+			if err != nil {
+				results = append(results, bulkItemResult{ID: id, Status: "failed", Error: err.Error()})
+				failed++
+				continue
+			}
+
+			results = append(results, bulkItemResult{ID: id, Status: "ok"})
+			changes = append(changes, auditChange{before, item})
+		}
+
+		if failed > 0 && !body.Partial {
+			return errBulkFailed
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBulkFailed) {
+		// the successes were rolled back with the rest
+		for i := range results {
+			if results[i].Status == "ok" {
+				results[i].Status = "rolled_back"
+			}
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Some items could not be processed; no changes were made",
+			"operation": body.Operation,
+			"succeeded": 0,
+			"failed":    failed,
+			"results":   results,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, ch := range changes {
+		action, after := models.AuditUpdate, interface{}(ch.after)
+		if body.Operation == bulkDelete {
+			action, after = models.AuditDelete, nil
+		}
+		if !recordAudit(c, ch.before.CompanyID, action, entityItem, ch.before.ID, ch.before, after) {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"operation": body.Operation,
+		"succeeded": len(changes),
+		"failed":    failed,
+		"results":   results,
+	})
+}
+
+// applyBulkOperation performs one bulk operation on a single item.
+func applyBulkOperation(tx *gorm.DB, item *models.Item, before models.Item, body bulkItemRequest,
+	category *models.Category, tag *models.Tag, userID uint) error {
+	switch body.Operation {
+	case bulkSetCategory:
+		var categoryID uint
+		if category != nil {
+			categoryID = category.ID
+		}
+		return reassignItemCategory(tx, item, categoryID, userID)
+
+	case bulkAdjustPrice:
+		item.Price = math.Round(item.Price*(1+body.Percent/100)*100) / 100
+		if err := updateItemVersioned(tx, item, "price"); err != nil {
+			return err
+		}
+		return saveItemRevision(tx, &before, *item, userID, models.AuditUpdate)
+
+	case bulkAddTag:
+		return tagItem(tx, item, tag, userID, true)
+
+	case bulkDelete:
+		return trashItem(tx, *item, userID)
+	}
+	return nil
+}
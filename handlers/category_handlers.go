@@ -1,13 +1,51 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
-	"github.com/Twinemukama/go-inventory-manager/database"
 	"github.com/Twinemukama/go-inventory-manager/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var errCategoryInUse = errors.New("category still has items")
+
+// itemReassignError reports an item that could not be moved out of a
+// category being deleted.
+type itemReassignError struct {
+	ItemID uint
+	Err    error
+}
+
+func (e *itemReassignError) Error() string {
+	if errors.Is(e.Err, errVersionConflict) {
+		return fmt.Sprintf("Item %d was changed by someone else, try again", e.ItemID)
+	}
+	return fmt.Sprintf("Item %d cannot be moved: %v", e.ItemID, e.Err)
+}
+
+func (e *itemReassignError) Unwrap() error { return e.Err }
+
+// reassignItemCategory moves an item to another category, or out of any
+// with categoryID 0, revalidating its attributes against the definitions
+// of the new category.
+func reassignItemCategory(tx *gorm.DB, item *models.Item, categoryID, userID uint) error {
+	before := *item
+	attrs, err := validateItemAttributes(tx, item.CompanyID, categoryID, item.Attributes)
+	if err != nil {
+		return err
+	}
+	item.CategoryID = categoryID
+	item.Attributes = attrs
+	if err := updateItemVersioned(tx, item, "category_id", "attributes"); err != nil {
+		return err
+	}
+	return saveItemRevision(tx, &before, *item, userID, models.AuditUpdate)
+}
+
 // POST /categories
 func CreateCategory(c *gin.Context) {
 	var category models.Category
@@ -32,11 +70,32 @@ func CreateCategory(c *gin.Context) {
 		category.CompanyID = companyID
 	}
 
-	if err := database.DB.Create(&category).Error; err != nil {
+	var parent *models.Category
+	if category.ParentID != nil {
+		parent = &models.Category{}
+		if err := db(c).First(parent, "id = ? AND company_id = ?", *category.ParentID, category.CompanyID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		category.Path = ""
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		category.Path = categoryPath(parent, category.ID)
+		return tx.Model(&category).Update("path", category.Path).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !recordAudit(c, category.CompanyID, models.AuditCreate, entityCategory, category.ID, nil, category) {
+		return
+	}
+
 	c.JSON(http.StatusCreated, category)
 }
 
@@ -47,7 +106,7 @@ func GetCategories(c *gin.Context) {
 	role := c.MustGet("role").(string)
 	companyID := c.MustGet("companyId").(uint)
 
-	query := database.DB.Preload("User").Preload("Company")
+	query := db(c).Preload("User").Preload("Company")
 
 	if role != "super_admin" {
 		query = query.Where("company_id = ?", companyID)
@@ -69,7 +128,7 @@ func GetCategory(c *gin.Context) {
 	role := c.MustGet("role").(string)
 	companyID := c.MustGet("companyId").(uint)
 
-	query := database.DB.Where("id = ?", id)
+	query := db(c).Where("id = ?", id)
 
 	if role != "super_admin" {
 		query = query.Preload("User").Preload("Company").Where("company_id = ?", companyID)
@@ -80,6 +139,7 @@ func GetCategory(c *gin.Context) {
 		return
 	}
 
+	setETag(c, category.Version)
 	c.JSON(http.StatusOK, category)
 }
 
@@ -92,7 +152,7 @@ func UpdateCategory(c *gin.Context) {
 	companyID := c.MustGet("companyId").(uint)
 	userID := c.MustGet("userId").(uint)
 
-	query := database.DB.Where("id = ? AND user_id = ?", id, userID)
+	query := db(c).Where("id = ? AND user_id = ?", id, userID)
 	if role != "super_admin" {
 		query = query.Where("company_id = ?", companyID)
 	}
@@ -113,13 +173,33 @@ func UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(c, category.Version, category) {
+		return
+	}
+
+	before := category
 	category.Name = updated.Name
+	if updated.Code != "" {
+		category.Code = updated.Code
+	}
 
-	if err := database.DB.Save(&category).Error; err != nil {
+	err := updateCategoryVersioned(db(c), &category, "name", "code")
+	if errors.Is(err, errVersionConflict) {
+		var current models.Category
+		db(c).First(&current, category.ID)
+		preconditionFailed(c, current.Version, current)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !recordAudit(c, category.CompanyID, models.AuditUpdate, entityCategory, category.ID, before, category) {
+		return
+	}
+
+	setETag(c, category.Version)
 	c.JSON(http.StatusOK, category)
 }
 
@@ -132,7 +212,7 @@ func DeleteCategory(c *gin.Context) {
 	companyID := c.MustGet("companyId").(uint)
 	userID := c.MustGet("userId").(uint)
 
-	query := database.DB.Where("id = ? AND user_id = ?", id, userID)
+	query := db(c).Where("id = ? AND user_id = ?", id, userID)
 	if role != "super_admin" {
 		query = query.Where("company_id = ?", companyID)
 	}
@@ -147,10 +227,273 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&category).Error; err != nil {
+	var children int64
+	if err := db(c).Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if children > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category has subcategories; move or delete them first"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+	// items still in the category must be reassigned or explicitly
+	// uncategorized, otherwise they would point at a deleted category
+	uncategorize := c.Query("uncategorize") == "true"
+	var target *models.Category
+	if v := c.Query("reassign_to"); v != "" {
+		if uncategorize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Use either reassign_to or uncategorize, not both"})
+			return
+		}
+		target = &models.Category{}
+		if err := db(c).First(target, "id = ? AND company_id = ?", v, category.CompanyID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target category not found"})
+			return
+		}
+		if target.ID == category.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot reassign items to the category being deleted"})
+			return
+		}
+	}
+
+	var affected []models.Item
+	type itemMove struct{ before, after models.Item }
+	var moves []itemMove
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		// trashed items are included so they cannot be restored into a deleted category
+		if err := tx.Unscoped().Where("category_id = ?", category.ID).Find(&affected).Error; err != nil {
+			return err
+		}
+
+		if len(affected) > 0 && target == nil && !uncategorize {
+			return errCategoryInUse
+		}
+		var newCategoryID uint
+		if target != nil {
+			newCategoryID = target.ID
+		}
+		for _, item := range affected {
+			before := item
+			if err := reassignItemCategory(tx.Unscoped(), &item, newCategoryID, userID); err != nil {
+				return &itemReassignError{ItemID: item.ID, Err: err}
+			}
+			moves = append(moves, itemMove{before, item})
+		}
+
+		return tx.Delete(&category).Error
+	})
+	var reassignErr *itemReassignError
+	if errors.As(err, &reassignErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": reassignErr.Error(), "item_id": reassignErr.ItemID})
+		return
+	}
+	if errors.Is(err, errCategoryInUse) {
+		itemsList := make([]gin.H, 0, len(affected))
+		for _, item := range affected {
+			itemsList = append(itemsList, gin.H{"id": item.ID, "name": item.Name, "sku": item.SKU})
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Category still has items; pass reassign_to=<category id> or uncategorize=true",
+			"items": itemsList,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, m := range moves {
+		if !recordAudit(c, m.after.CompanyID, models.AuditUpdate, entityItem, m.after.ID, m.before, m.after) {
+			return
+		}
+	}
+
+	after := gin.H{"items_affected": len(affected), "uncategorized": uncategorize}
+	if target != nil {
+		after["reassigned_to"] = target.ID
+	}
+	if !recordAudit(c, category.CompanyID, models.AuditDelete, entityCategory, category.ID, category, after) {
+		return
+	}
+
+	resp := gin.H{"message": "Category deleted", "items_affected": len(affected)}
+	if target != nil {
+		resp["reassigned_to"] = target.ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /categories/tree
+func GetCategoryTree(c *gin.Context) {
+	var categories []models.Category
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	query := db(c).Order("path")
+
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	} else if v := c.Query("company_id"); v != "" {
+		query = query.Where("company_id = ?", v)
+	}
+
+	if err := query.Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildCategoryTree(categories))
+}
+
+// PUT /categories/:id/move
+func MoveCategory(c *gin.Context) {
+	id := c.Param("id")
+	var category models.Category
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can move categories"})
+		return
+	}
+
+	query := db(c).Where("id = ?", id)
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	if err := query.First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	if !checkIfMatch(c, category.Version, category) {
+		return
+	}
+
+	var body struct {
+		ParentID *uint `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var parent *models.Category
+	if body.ParentID != nil {
+		parent = &models.Category{}
+		if err := db(c).First(parent, "id = ? AND company_id = ?", *body.ParentID, category.CompanyID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+		// a category cannot be moved beneath itself or one of its descendants
+		if strings.HasPrefix(parent.Path, category.Path) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a category into its own subtree"})
+			return
+		}
+	}
+
+	before := category
+	oldPath := category.Path
+	newPath := categoryPath(parent, category.ID)
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		// the subtree takes on the attributes of its new ancestors, so they
+		// get the same checks as attributes declared on the parent directly
+		subtree, err := subtreeCategoryIDs(tx, &category)
+		if err != nil {
+			return err
+		}
+		gained, err := gainedAttributes(tx, &category, parent)
+		if err != nil {
+			return err
+		}
+		if err := checkInheritedAttributes(tx, subtree, gained); err != nil {
+			return err
+		}
+		if err := applyAttributeDefaults(tx, subtree, gained); err != nil {
+			return err
+		}
+
+		category.ParentID = body.ParentID
+		if err := updateCategoryVersioned(tx, &category, "parent_id"); err != nil {
+			return err
+		}
+		// rewrite the path prefix of the category and every descendant
+		return tx.Exec(`UPDATE categories SET path = ? || substr(path, ?) WHERE company_id = ? AND path LIKE ?`,
+			newPath, len(oldPath)+1, category.CompanyID, escapeLike(oldPath)+"%").Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		var current models.Category
+		db(c).First(&current, category.ID)
+		preconditionFailed(c, current.Version, current)
+		return
+	}
+	if errors.Is(err, errAttributeKeyTaken) || errors.Is(err, errAttributeNeedsDefault) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	category.Path = newPath
+	if !recordAudit(c, category.CompanyID, models.AuditMove, entityCategory, category.ID, before, category) {
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, category)
+}
+
+// gainedAttributes returns the attributes a category inherits under parent
+// that it does not inherit where it is now. A nil parent adds none.
+func gainedAttributes(conn *gorm.DB, category, parent *models.Category) ([]models.CategoryAttribute, error) {
+	if parent == nil {
+		return nil, nil
+	}
+	current, err := categoryAttributes(conn, category)
+	if err != nil {
+		return nil, err
+	}
+	next, err := categoryAttributes(conn, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	have := make(map[uint]bool, len(current))
+	for _, a := range current {
+		have[a.ID] = true
+	}
+	var gained []models.CategoryAttribute
+	for _, a := range next {
+		if !have[a.ID] {
+			gained = append(gained, a)
+		}
+	}
+	return gained, nil
+}
+
+// updateCategoryVersioned writes the given fields of category and bumps its
+// version, returning errVersionConflict if the row changed since it was read.
+func updateCategoryVersioned(tx *gorm.DB, category *models.Category, fields ...string) error {
+	readVersion := category.Version
+	category.Version++
+
+	res := tx.Model(category).Where("version = ?", readVersion).
+		Select(append(fields, "version", "updated_at")).Updates(category)
+	if res.Error != nil {
+		category.Version = readVersion
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		category.Version = readVersion
+		return errVersionConflict
+	}
+	return nil
 }
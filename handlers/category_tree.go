@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"gorm.io/gorm"
+)
+
+// categoryPath returns the materialized path of a category placed under
+// parent, or at the root when parent is nil.
+func categoryPath(parent *models.Category, id uint) string {
+	if parent == nil {
+		return fmt.Sprintf("/%d/", id)
+	}
+	return fmt.Sprintf("%s%d/", parent.Path, id)
+}
+
+// buildCategoryTree nests a flat list of categories under their parents.
+// Categories whose parent is not in the list are returned as roots.
+func buildCategoryTree(categories []models.Category) []*models.Category {
+	byID := make(map[uint]*models.Category, len(categories))
+	for i := range categories {
+		categories[i].Children = []*models.Category{}
+		byID[categories[i].ID] = &categories[i]
+	}
+
+	roots := []*models.Category{}
+	for i := range categories {
+		cat := &categories[i]
+		if cat.ParentID != nil {
+			if parent, ok := byID[*cat.ParentID]; ok {
+				parent.Children = append(parent.Children, cat)
+				continue
+			}
+		}
+		roots = append(roots, cat)
+	}
+	return roots
+}
+
+// categorySubtreeIDs returns a subquery selecting the ids of a category and
+// all of its descendants.
+func categorySubtreeIDs(categoryID uint64) *gorm.DB {
+	return database.DB.Model(&models.Category{}).Select("id").
+		Where("path LIKE (?) || '%'", database.DB.Model(&models.Category{}).Select("path").Where("id = ?", categoryID))
+}
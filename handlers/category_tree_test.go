@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+)
+
+// treeShape renders a tree as ids nested under their parent's id.
+func treeShape(nodes []*models.Category) map[uint]interface{} {
+	shape := make(map[uint]interface{}, len(nodes))
+	for _, n := range nodes {
+		shape[n.ID] = treeShape(n.Children)
+	}
+	return shape
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	parent := func(id uint) *uint { return &id }
+	tests := []struct {
+		name       string
+		categories []models.Category
+		want       map[uint]interface{}
+	}{
+		{name: "empty", want: map[uint]interface{}{}},
+		{
+			name:       "flat roots",
+			categories: []models.Category{{ID: 1}, {ID: 2}},
+			want:       map[uint]interface{}{1: map[uint]interface{}{}, 2: map[uint]interface{}{}},
+		},
+		{
+			name: "nested",
+			categories: []models.Category{
+				{ID: 1}, {ID: 2, ParentID: parent(1)}, {ID: 3, ParentID: parent(2)}, {ID: 4, ParentID: parent(1)},
+			},
+			want: map[uint]interface{}{1: map[uint]interface{}{
+				2: map[uint]interface{}{3: map[uint]interface{}{}},
+				4: map[uint]interface{}{},
+			}},
+		},
+		{
+			name:       "child listed before parent",
+			categories: []models.Category{{ID: 3, ParentID: parent(2)}, {ID: 2}},
+			want:       map[uint]interface{}{2: map[uint]interface{}{3: map[uint]interface{}{}}},
+		},
+		{
+			name:       "parent not in list becomes root",
+			categories: []models.Category{{ID: 5, ParentID: parent(9)}, {ID: 6, ParentID: parent(5)}},
+			want:       map[uint]interface{}{5: map[uint]interface{}{6: map[uint]interface{}{}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := treeShape(buildCategoryTree(tt.categories))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCategoryTreeLeavesHaveEmptyChildren(t *testing.T) {
+	roots := buildCategoryTree([]models.Category{{ID: 1}})
+	// serialised as [] rather than null
+	if roots[0].Children == nil {
+		t.Error("leaf children are nil")
+	}
+}
+
+func TestCategoryPath(t *testing.T) {
+	if got := categoryPath(nil, 4); got != "/4/" {
+		t.Errorf("root path = %q", got)
+	}
+	if got := categoryPath(&models.Category{Path: "/1/4/"}, 9); got != "/1/4/9/" {
+		t.Errorf("child path = %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLowStockThreshold is the available quantity at or below which an
+// item counts as low on stock, unless ?low_stock_threshold is given.
+const defaultLowStockThreshold = 10
+
+// topMoversLimit is how many items the dashboard lists as top movers.
+const topMoversLimit = 5
+
+// bookkeepingReferences mark ledger rows that record quantities written
+// directly to an item rather than stock moving in or out, so the dashboard's
+// movement figures leave them out.
+var bookkeepingReferences = []string{
+	models.ReferenceOpeningBalance,
+	models.ReferenceItemEdit,
+	models.ReferenceItemTrash,
+	models.ReferenceItemRestore,
+}
+
+// topMover is an item ranked by the quantity that went out this week.
+type topMover struct {
+	ItemID   uint    `json:"item_id"`
+	Name     string  `json:"name"`
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+// GET /dashboard
+func GetDashboard(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	threshold := defaultLowStockThreshold
+	if v := c.Query("low_stock_threshold"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid low_stock_threshold"})
+			return
+		}
+		threshold = n
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// weeks start on Monday, like date_trunc('week')
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	var stock struct {
+		TotalSKUs       int64 `gorm:"column:total_skus"`
+		TotalQuantity   int64
+		TotalValue      float64
+		LowStockCount   int64
+		OutOfStockCount int64
+	}
+	err := db(c).Model(&models.Item{}).
+		Select(`COUNT(*) AS total_skus,
+			COALESCE(SUM(items.quantity), 0) AS total_quantity,
+			COALESCE(SUM(items.quantity * items.cost), 0) AS total_value,
+			COUNT(*) FILTER (WHERE `+itemAvailableExpr+` > 0 AND `+itemAvailableExpr+` <= ?) AS low_stock_count,
+			COUNT(*) FILTER (WHERE `+itemAvailableExpr+` <= 0) AS out_of_stock_count`, threshold).
+		Where("items.company_id = ?", companyID).
+		Scan(&stock).Error
+
+	var pendingRequests int64
+	if err == nil {
+		err = db(c).Model(&models.PendingRequest{}).
+			Where("status = ? AND target_id = ?", "pending", companyID).
+			Count(&pendingRequests).Error
+	}
+
+	var movements struct {
+		Count       int64
+		InQuantity  int64
+		OutQuantity int64
+	}
+	if err == nil {
+		err = db(c).Model(&models.Transaction{}).
+			Select(`COUNT(*) AS count,
+				COALESCE(SUM(quantity) FILTER (WHERE type = 'IN'), 0) AS in_quantity,
+				COALESCE(SUM(quantity) FILTER (WHERE type = 'OUT'), 0) AS out_quantity`).
+			Where("company_id = ? AND created_at >= ? AND COALESCE(reference_type, '') NOT IN ?",
+				companyID, today, bookkeepingReferences).
+			Scan(&movements).Error
+	}
+
+	topMovers := []topMover{}
+	if err == nil {
+		err = db(c).Table("transactions").
+			Select(`transactions.item_id, COALESCE(items.name, '') AS name, COALESCE(items.sku, '') AS sku,
+				SUM(transactions.quantity) AS quantity,
+				SUM(transactions.quantity * transactions.unit_cost) AS value`).
+			Joins("LEFT JOIN items ON items.id = transactions.item_id").
+			Where("transactions.company_id = ? AND transactions.type = ? AND transactions.created_at >= ? AND COALESCE(transactions.reference_type, '') NOT IN ?",
+				companyID, models.TransactionOut, weekStart, bookkeepingReferences).
+			Group("transactions.item_id, items.name, items.sku").
+			Order("quantity DESC, transactions.item_id").
+			Limit(topMoversLimit).
+			Scan(&topMovers).Error
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_skus":          stock.TotalSKUs,
+		"total_quantity":      stock.TotalQuantity,
+		"total_stock_value":   stock.TotalValue,
+		"low_stock_threshold": threshold,
+		"low_stock_count":     stock.LowStockCount,
+		"out_of_stock_count":  stock.OutOfStockCount,
+		"pending_requests":    pendingRequests,
+		"movements_today": gin.H{
+			"count":        movements.Count,
+			"in_quantity":  movements.InQuantity,
+			"out_quantity": movements.OutQuantity,
+		},
+		"top_movers_since": weekStart.Format("2006-01-02"),
+		"top_movers":       topMovers,
+	})
+}
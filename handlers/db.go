@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// db returns the database handle for the current request: the enclosing
+// transaction of an all-or-nothing batch, or the shared pool.
+func db(c *gin.Context) *gorm.DB {
+	return database.Conn(c.Request.Context())
+}
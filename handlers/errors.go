@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestError is an error caused by the request rather than the server.
+// Helpers running inside transactions return it so handlers can tell a
+// rejected request from a database failure.
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// badRequest returns a requestError answered with 400.
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+// conflict returns a requestError answered with 409.
+func conflict(format string, args ...interface{}) error {
+	return &requestError{http.StatusConflict, fmt.Sprintf(format, args...)}
+}
+
+// respondError answers a requestError with its own status and any other
+// error with 500.
+func respondError(c *gin.Context, err error) {
+	var re *requestError
+	if errors.As(err, &re) {
+		c.JSON(re.status, gin.H{"error": re.msg})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errVersionConflict is returned from update transactions when the row was
+// changed by someone else after it was read.
+var errVersionConflict = errors.New("version conflict")
+
+func etagFor(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag exposes the version of the returned representation.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", etagFor(version))
+}
+
+// requireIfMatch reports whether PUT requests must carry If-Match. It is off
+// by default so existing clients keep working.
+func requireIfMatch() bool {
+	return os.Getenv("REQUIRE_IF_MATCH") == "true"
+}
+
+// checkIfMatch validates the If-Match header against the current version of
+// a resource. On failure it writes the response, including the current
+// representation for a stale version, and returns false. If-Match uses the
+// strong comparison, so weak tags never match.
+func checkIfMatch(c *gin.Context, version int, current interface{}) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if requireIfMatch() {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return false
+		}
+		return true
+	}
+
+	want := etagFor(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == want {
+			return true
+		}
+	}
+
+	preconditionFailed(c, version, current)
+	return false
+}
+
+// preconditionFailed responds 412 with the current representation so the
+// client can merge and retry.
+func preconditionFailed(c *gin.Context, version int, current interface{}) {
+	setETag(c, version)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "The resource was modified by someone else",
+		"current": current,
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", true},
+		{`"3"`, true},
+		{`"2", "3"`, true},
+		{"*", true},
+		{`"2"`, false},
+		{`W/"3"`, false},
+		{`W/"2", W/"3"`, false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/items/1", nil)
+		if tt.header != "" {
+			c.Request.Header.Set("If-Match", tt.header)
+		}
+
+		if got := checkIfMatch(c, 3, nil); got != tt.want {
+			t.Errorf("If-Match %q: got %v, want %v", tt.header, got, tt.want)
+		}
+		if !tt.want && w.Code != http.StatusPreconditionFailed {
+			t.Errorf("If-Match %q: status %d, want %d", tt.header, w.Code, http.StatusPreconditionFailed)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// itemSortColumns whitelists the columns ListItems may order by.
+var itemSortColumns = map[string]keysetColumn{
+	"name":       {"items.name", keysetString},
+	"sku":        {"items.sku", keysetString},
+	"price":      {"items.price", keysetFloat},
+	"quantity":   {"items.quantity", keysetInt},
+	"created_at": {"items.created_at", keysetTime},
+	"updated_at": {"items.updated_at", keysetTime},
+}
+
+// itemSearchExpr matches the expression of idx_items_search_trgm so text
+// search can use the trigram index.
+const itemSearchExpr = "(coalesce(items.name, '') || ' ' || coalesce(items.sku, '') || ' ' || coalesce(items.description, ''))"
+
+// itemAvailableExpr is the sellable quantity of an item: its quantity on
+// hand less the stock held in other statuses.
+const itemAvailableExpr = "(items.quantity - COALESCE((SELECT SUM(item_stocks.quantity) FROM item_stocks WHERE item_stocks.item_id = items.id), 0))"
+
+// applyItemFilters narrows an item query using the search and filter query
+// params of GET /items.
+func applyItemFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where(itemSearchExpr+" ILIKE ?", "%"+escapeLike(q)+"%")
+	}
+
+	if v := c.Query("category_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category_id")
+		}
+		if c.Query("include_subcategories") == "true" {
+			query = query.Where("items.category_id IN (?)", categorySubtreeIDs(id))
+		} else {
+			query = query.Where("items.category_id = ?", id)
+		}
+	}
+
+	floatFilters := []struct{ param, clause string }{
+		{"min_price", "items.price >= ?"},
+		{"max_price", "items.price <= ?"},
+	}
+	for _, f := range floatFilters {
+		if v := c.Query(f.param); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", f.param)
+			}
+			query = query.Where(f.clause, n)
+		}
+	}
+
+	intFilters := []struct{ param, clause string }{
+		{"min_quantity", "items.quantity >= ?"},
+		{"max_quantity", "items.quantity <= ?"},
+		{"min_available", itemAvailableExpr + " >= ?"},
+		{"max_available", itemAvailableExpr + " <= ?"},
+	}
+	for _, f := range intFilters {
+		if v := c.Query(f.param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", f.param)
+			}
+			query = query.Where(f.clause, n)
+		}
+	}
+
+	dateFilters := []struct{ param, clause string }{
+		{"created_from", "items.created_at >= ?"},
+		{"created_to", "items.created_at <= ?"},
+		{"updated_from", "items.updated_at >= ?"},
+		{"updated_to", "items.updated_at <= ?"},
+	}
+	for _, f := range dateFilters {
+		if v := c.Query(f.param); v != "" {
+			t, err := parseDateParam(v, strings.HasSuffix(f.param, "_to"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s, expected YYYY-MM-DD or RFC3339", f.param)
+			}
+			query = query.Where(f.clause, t)
+		}
+	}
+
+	if v := c.Query("tags_any"); v != "" {
+		ids, err := parseIDList(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tags_any")
+		}
+		query = query.Where("items.id IN (?)",
+			db(c).Table("item_tags").Select("item_id").Where("tag_id IN ?", ids))
+	}
+
+	if v := c.Query("tags_all"); v != "" {
+		ids, err := parseIDList(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tags_all")
+		}
+		query = query.Where("items.id IN (?)",
+			db(c).Table("item_tags").Select("item_id").Where("tag_id IN ?", ids).
+				Group("item_id").Having("COUNT(DISTINCT tag_id) = ?", len(ids)))
+	}
+
+	for key, v := range c.QueryMap("attr") {
+		if !attributeKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid attribute %q", key)
+		}
+		query = query.Where("items.attributes ->> ? = ?", key, v)
+	}
+
+	attrRanges := []struct{ param, op string }{
+		{"attr_min", ">="},
+		{"attr_max", "<="},
+	}
+	for _, r := range attrRanges {
+		for key, v := range c.QueryMap(r.param) {
+			if !attributeKeyPattern.MatchString(key) {
+				return nil, fmt.Errorf("invalid attribute %q", key)
+			}
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				// only compare attributes actually stored as numbers
+				query = query.Where(fmt.Sprintf(
+					"CASE WHEN jsonb_typeof(items.attributes -> ?) = 'number' THEN (items.attributes ->> ?)::numeric END %s ?", r.op),
+					key, key, n)
+			} else {
+				// dates are stored as YYYY-MM-DD and compare correctly as text
+				query = query.Where(fmt.Sprintf("items.attributes ->> ? %s ?", r.op), key, v)
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// itemSortValue returns the value of the sort column for an item, used to
+// build the cursor of the next page.
+func itemSortValue(item models.Item, key string) interface{} {
+	switch key {
+	case "name":
+		return item.Name
+	case "sku":
+		return item.SKU
+	case "price":
+		return item.Price
+	case "quantity":
+		return item.Quantity
+	case "created_at":
+		return item.CreatedAt
+	case "updated_at":
+		return item.UpdatedAt
+	}
+	return nil
+}
+
+// parseIDList parses a comma separated list of ids such as "1,4,9".
+func parseIDList(v string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(v, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return uniqueUints(ids), nil
+}
+
+// parseDateParam accepts a plain date or an RFC3339 timestamp. A plain date
+// used as an upper bound covers the whole day.
+func parseDateParam(v string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user supplied search text.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -1,13 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"strings"
 
-	"github.com/Twinemukama/go-inventory-manager/database"
 	"github.com/Twinemukama/go-inventory-manager/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // POST /items
@@ -23,6 +25,12 @@ func CreateItem(c *gin.Context) {
 	userID := c.MustGet("userId").(uint)
 
 	item.UserID = userID
+	item.Tags = nil // tags are assigned through POST /items/:id/tags
+
+	if err := checkItemAmounts(item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Only super admins might specify a company in the payload
 	if role == "super_admin" && item.CompanyID != 0 {
@@ -32,28 +40,61 @@ func CreateItem(c *gin.Context) {
 		item.CompanyID = companyID
 	}
 
-	if err := database.DB.Create(&item).Error; err != nil {
+	attrs, err := validateItemAttributes(db(c), item.CompanyID, item.CategoryID, item.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item.Attributes = attrs
+
+	item.SKU = strings.TrimSpace(item.SKU)
+	if item.SKU != "" {
+		taken, err := skuTaken(db(c), item.CompanyID, item.SKU, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "SKU already in use"})
+			return
+		}
+	}
+
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		if item.SKU == "" {
+			sku, err := generateSKU(tx, item.CompanyID, item.CategoryID)
+			if err != nil {
+				return err
+			}
+			item.SKU = sku
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		if err := saveItemRevision(tx, nil, item, userID, models.AuditCreate); err != nil {
+			return err
+		}
+		return postQuantityChange(tx, item, 0, item.Quantity, userID, models.ReferenceOpeningBalance)
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "SKU already in use"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !recordAudit(c, item.CompanyID, models.AuditCreate, entityItem, item.ID, nil, item) {
+		return
+	}
+
 	c.JSON(http.StatusCreated, item)
 }
 
 // GET /items
 func ListItems(c *gin.Context) {
-	// Parse query params
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "10")
-	page, _ := strconv.Atoi(pageStr)
-	limit, _ := strconv.Atoi(limitStr)
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 10
-	}
-	offset := (page - 1) * limit
+	pg := parsePageParams(c)
 
 	role := c.MustGet("role").(string)
 	companyID := c.MustGet("companyId").(uint)
@@ -61,26 +102,75 @@ func ListItems(c *gin.Context) {
 	var items []models.Item
 	var total int64
 
-	query := database.DB.Model(&models.Item{}).Preload("User").Preload("Company")
+	query := db(c).Model(&models.Item{}).Preload("User").Preload("Company").Preload("Tags")
 
 	if role != "super_admin" {
 		query = query.Where("company_id = ?", companyID)
 	}
 
+	query, err := applyItemFilters(c, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort, err := parseSort(c.Query("sort"), itemSortColumns, "items.id", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if pg.Keyset {
+		if pg.Cursor != "" {
+			if query, err = sort.after(query, pg.Cursor); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// fetch one extra row to learn whether another page exists
+		if err := query.Order(sort.orderClause()).Limit(pg.Limit + 1).Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var nextCursor string
+		if len(items) > pg.Limit {
+			items = items[:pg.Limit]
+			last := items[len(items)-1]
+			nextCursor = sort.cursorFor(itemSortValue(last, sort.Key), last.ID)
+		}
+		if err := fillAvailable(db(c), items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"items":       items,
+			"limit":       pg.Limit,
+			"next_cursor": nextCursor,
+		})
+		return
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := query.Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+	if err := query.Order(sort.orderClause()).Limit(pg.Limit).Offset(pg.offset()).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := fillAvailable(db(c), items); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"items": items,
-		"page":  page,
-		"limit": limit,
+		"page":  pg.Page,
+		"limit": pg.Limit,
 		"total": total,
 	})
 }
@@ -93,10 +183,10 @@ func GetItem(c *gin.Context) {
 	role := c.MustGet("role").(string)
 	companyID := c.MustGet("companyId").(uint)
 
-	query := database.DB.Where("id = ?", id)
+	query := db(c).Where("id = ?", id)
 
 	if role != "super_admin" {
-		query = query.Preload("User").Preload("Company").Where("company_id = ?", companyID)
+		query = query.Preload("User").Preload("Company").Preload("Tags").Where("company_id = ?", companyID)
 	}
 
 	if err := query.First(&item).Error; err != nil {
@@ -104,6 +194,13 @@ func GetItem(c *gin.Context) {
 		return
 	}
 
+	var err error
+	if item.Available, err = stockInStatus(db(c), item, models.StockAvailable); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, item.Version)
 	c.JSON(http.StatusOK, item)
 }
 
@@ -116,7 +213,7 @@ func UpdateItem(c *gin.Context) {
 	role := c.MustGet("role").(string)
 	companyID := c.MustGet("companyId").(uint)
 
-	if err := database.DB.First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
+	if err := db(c).First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
 		return
 	}
@@ -125,24 +222,70 @@ func UpdateItem(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this item"})
 		return
 	}
+
+	if !checkIfMatch(c, item.Version, item) {
+		return
+	}
+
 	var input models.Item
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := checkItemAmounts(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attrs, err := validateItemAttributes(db(c), item.CompanyID, input.CategoryID, input.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkHeldStock(c, item, input.Quantity) {
+		return
+	}
+
+	before := item
 
 	// Update allowed fields
 	item.Name = input.Name
 	item.Description = input.Description
 	item.Price = input.Price
+	item.Cost = input.Cost
 	item.Quantity = input.Quantity
 	item.CategoryID = input.CategoryID
-
-	if err := database.DB.Save(&item).Error; err != nil {
+	item.Attributes = attrs
+
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		if err := updateItemVersioned(tx, &item, itemEditableFields...); err != nil {
+			return err
+		}
+		if err := saveItemRevision(tx, &before, item, userID, models.AuditUpdate); err != nil {
+			return err
+		}
+		return postQuantityChange(tx, item, before.Quantity, item.Quantity, userID, models.ReferenceItemEdit)
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondItemConflict(c, item.ID)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// added stock may have gone straight to waiting backorders
+	if item.Quantity > before.Quantity {
+		db(c).First(&item, item.ID)
+	}
+
+	if !recordAudit(c, item.CompanyID, models.AuditUpdate, entityItem, item.ID, before, item) {
+		return
+	}
+
+	setETag(c, item.Version)
 	c.JSON(http.StatusOK, item)
 }
 
@@ -155,7 +298,7 @@ func DeleteItem(c *gin.Context) {
 	role := c.MustGet("role").(string)
 	companyID := c.MustGet("companyId").(uint)
 
-	if err := database.DB.First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
+	if err := db(c).First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
 		return
 	}
@@ -165,10 +308,91 @@ func DeleteItem(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&item).Error; err != nil {
+	// soft delete: the item moves to the trash and keeps its tags and
+	// attachments until it is restored or purged
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		return trashItem(tx, item, userID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !recordAudit(c, item.CompanyID, models.AuditDelete, entityItem, item.ID, item, nil) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted"})
 }
+
+// itemEditableFields are the columns PUT /items/:id may change.
+var itemEditableFields = []string{"name", "description", "price", "cost", "quantity", "category_id", "attributes"}
+
+// updateItemVersioned writes the given fields of item and bumps its version,
+// provided nobody else updated the row since it was read. It returns
+// errVersionConflict otherwise.
+func updateItemVersioned(tx *gorm.DB, item *models.Item, fields ...string) error {
+	readVersion := item.Version
+	item.Version++
+
+	res := tx.Model(item).Where("version = ?", readVersion).
+		Select(append(fields, "version", "updated_at")).Updates(item)
+	if res.Error != nil {
+		item.Version = readVersion
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		item.Version = readVersion
+		return errVersionConflict
+	}
+	return nil
+}
+
+// respondItemConflict answers a lost update race with 412 and the item's
+// current representation.
+func respondItemConflict(c *gin.Context, id uint) {
+	var current models.Item
+	if err := db(c).Preload("Tags").First(&current, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+	preconditionFailed(c, current.Version, current)
+}
+
+// checkHeldStock rejects setting an item's quantity below what it holds in
+// non-sellable statuses; those units have to be moved out first.
+func checkHeldStock(c *gin.Context, item models.Item, quantity int) bool {
+	held, err := heldStock(db(c), item.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if quantity < held {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quantity is below the stock held as reserved, damaged or quarantined", "held": held})
+		return false
+	}
+	return true
+}
+
+// trashItem soft deletes an item. Its stock leaves the ledger but the
+// cached quantity is kept for a restore.
+func trashItem(tx *gorm.DB, item models.Item, userID uint) error {
+	if err := tx.Delete(&item).Error; err != nil {
+		return err
+	}
+	return postQuantityChange(tx, item, item.Quantity, 0, userID, models.ReferenceItemTrash)
+}
+
+// checkItemAmounts applies the rules PATCH enforces on price, cost and
+// quantity to a full item payload.
+func checkItemAmounts(item models.Item) error {
+	switch {
+	case item.Price < 0:
+		return fmt.Errorf("price must be a non-negative number")
+	case item.Cost < 0:
+		return fmt.Errorf("cost must be a non-negative number")
+	case item.Quantity < 0:
+		return fmt.Errorf("quantity must be a non-negative integer")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+)
+
+const entityNotification = "notification"
+
+// GET /notifications
+func GetNotifications(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+
+	var notifications []models.Notification
+	query := db(c).Where("user_id = ?", userID).Order("id DESC").Limit(100)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// PUT /notifications/:id/read
+func MarkNotificationRead(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+
+	var notification models.Notification
+	if err := db(c).First(&notification, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		before := notification
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := db(c).Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !recordAudit(c, notification.CompanyID, models.AuditUpdate, entityNotification, notification.ID, before, notification) {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
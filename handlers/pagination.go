@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Kinds of values a keyset column can hold, used to decode cursor values back
+// into properly typed query arguments.
+const (
+	keysetString = "string"
+	keysetFloat  = "float"
+	keysetInt    = "int"
+	keysetTime   = "time"
+)
+
+// keysetColumn is a sortable column that can be used for cursor pagination.
+type keysetColumn struct {
+	Column string
+	Kind   string
+}
+
+// keysetSort describes the ordering a cursor page is taken over. An empty
+// Column orders by the id alone.
+type keysetSort struct {
+	Key      string
+	Column   string
+	Kind     string
+	IDColumn string
+	Desc     bool
+}
+
+// pageCursor is the decoded form of the opaque cursor handed to clients.
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v,omitempty"`
+	ID    uint            `json:"id"`
+}
+
+// parseSort resolves ?sort=key or ?sort=-key against a whitelist of columns.
+func parseSort(sort string, columns map[string]keysetColumn, idColumn string, defaultDesc bool) (keysetSort, error) {
+	s := keysetSort{IDColumn: idColumn, Desc: defaultDesc}
+	if sort == "" {
+		return s, nil
+	}
+
+	s.Desc = false
+	if sort[0] == '-' {
+		s.Desc = true
+		sort = sort[1:]
+	}
+
+	col, ok := columns[sort]
+	if !ok {
+		return s, fmt.Errorf("cannot sort by %q", sort)
+	}
+	s.Key, s.Column, s.Kind = sort, col.Column, col.Kind
+	return s, nil
+}
+
+func (s keysetSort) direction() string {
+	if s.Desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// orderClause returns the ORDER BY clause, always tie-breaking on the id so
+// that both offset and cursor pages are stable.
+func (s keysetSort) orderClause() string {
+	if s.Column == "" {
+		return fmt.Sprintf("%s %s", s.IDColumn, s.direction())
+	}
+	return fmt.Sprintf("%s %s, %s %s", s.Column, s.direction(), s.IDColumn, s.direction())
+}
+
+// after restricts the query to rows following the given cursor.
+func (s keysetSort) after(query *gorm.DB, raw string) (*gorm.DB, error) {
+	cur, err := decodeCursor(raw)
+	if err != nil || cur.Sort != s.Key {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	op := ">"
+	if s.Desc {
+		op = "<"
+	}
+
+	if s.Column == "" {
+		return query.Where(fmt.Sprintf("%s %s ?", s.IDColumn, op), cur.ID), nil
+	}
+
+	value, err := s.decodeValue(cur.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	clause := fmt.Sprintf("(%s, %s) %s (?, ?)", s.Column, s.IDColumn, op)
+	return query.Where(clause, value, cur.ID), nil
+}
+
+// cursorFor builds the cursor pointing just past a row with the given sort
+// value and id.
+func (s keysetSort) cursorFor(value interface{}, id uint) string {
+	cur := pageCursor{Sort: s.Key, ID: id}
+	if s.Column != "" {
+		cur.Value, _ = json.Marshal(value)
+	}
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (s keysetSort) decodeValue(raw json.RawMessage) (interface{}, error) {
+	switch s.Kind {
+	case keysetFloat:
+		var v float64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case keysetInt:
+		var v int64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case keysetTime:
+		var v time.Time
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	default:
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+func decodeCursor(raw string) (pageCursor, error) {
+	var cur pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(data, &cur)
+	return cur, err
+}
+
+// pageParams holds the pagination query params shared by list endpoints.
+// Offset mode (page/limit) is the default; passing ?pagination=cursor or a
+// ?cursor switches to keyset mode, which skips the COUNT query.
+type pageParams struct {
+	Page   int
+	Limit  int
+	Cursor string
+	Keyset bool
+}
+
+func parsePageParams(c *gin.Context) pageParams {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	cursor, hasCursor := c.GetQuery("cursor")
+	return pageParams{
+		Page:   page,
+		Limit:  limit,
+		Cursor: cursor,
+		Keyset: hasCursor || c.Query("pagination") == "cursor",
+	}
+}
+
+func (p pageParams) offset() int {
+	return (p.Page - 1) * p.Limit
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// readMergePatch decodes a JSON Merge Patch (RFC 7396) request body into its
+// top level members, rejecting members outside allowed.
+func readMergePatch(c *gin.Context, allowed ...string) (map[string]json.RawMessage, error) {
+	ct := c.ContentType()
+	if ct != "" && ct != "application/json" && ct != "application/merge-patch+json" {
+		return nil, fmt.Errorf("content type must be application/merge-patch+json or application/json")
+	}
+
+	raw, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request body")
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &patch); err != nil || patch == nil {
+		return nil, fmt.Errorf("body must be a JSON object")
+	}
+
+	for key := range patch {
+		ok := false
+		for _, a := range allowed {
+			if key == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("field %q cannot be patched", key)
+		}
+	}
+	return patch, nil
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// mergePatchObject applies an RFC 7396 merge patch to a JSON object: null
+// members are removed, objects are merged recursively and anything else
+// replaces the target value.
+func mergePatchObject(target, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(target))
+	for k, v := range target {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			existing, _ := result[k].(map[string]interface{})
+			result[k] = mergePatchObject(existing, sub)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// PATCH /items/:id
+func PatchItem(c *gin.Context) {
+	id := c.Param("id")
+	var item models.Item
+
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if err := db(c).First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this item"})
+		return
+	}
+
+	if !checkIfMatch(c, item.Version, item) {
+		return
+	}
+
+	patch, err := readMergePatch(c, "name", "sku", "description", "price", "cost", "quantity", "category_id", "attributes")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := item
+	fields, err := applyItemPatch(&item, patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(fields) == 0 {
+		setETag(c, item.Version)
+		c.JSON(http.StatusOK, item)
+		return
+	}
+
+	if item.Quantity != before.Quantity && !checkHeldStock(c, item, item.Quantity) {
+		return
+	}
+
+	if item.SKU != before.SKU {
+		taken, err := skuTaken(db(c), item.CompanyID, item.SKU, item.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "SKU already in use"})
+			return
+		}
+	}
+
+	// attributes are revalidated whenever they or the category change
+	if _, ok := patch["attributes"]; ok || item.CategoryID != before.CategoryID {
+		attrs, err := validateItemAttributes(db(c), item.CompanyID, item.CategoryID, item.Attributes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		item.Attributes = attrs
+		fields = appendUnique(fields, "attributes")
+	}
+
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		if err := updateItemVersioned(tx, &item, fields...); err != nil {
+			return err
+		}
+		if err := saveItemRevision(tx, &before, item, userID, models.AuditUpdate); err != nil {
+			return err
+		}
+		return postQuantityChange(tx, item, before.Quantity, item.Quantity, userID, models.ReferenceItemEdit)
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondItemConflict(c, item.ID)
+		return
+	}
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "SKU already in use"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// added stock may have gone straight to waiting backorders
+	if item.Quantity > before.Quantity {
+		db(c).First(&item, item.ID)
+	}
+
+	if !recordAudit(c, item.CompanyID, models.AuditUpdate, entityItem, item.ID, before, item) {
+		return
+	}
+
+	setETag(c, item.Version)
+	c.JSON(http.StatusOK, item)
+}
+
+// applyItemPatch validates each supplied member and copies it onto item,
+// returning the columns that changed.
+func applyItemPatch(item *models.Item, patch map[string]json.RawMessage) ([]string, error) {
+	var fields []string
+
+	if raw, ok := patch["name"]; ok {
+		var name string
+		if isJSONNull(raw) || json.Unmarshal(raw, &name) != nil || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("name must be a non-empty string")
+		}
+		item.Name = name
+		fields = append(fields, "name")
+	}
+
+	if raw, ok := patch["sku"]; ok {
+		var sku string
+		if isJSONNull(raw) || json.Unmarshal(raw, &sku) != nil || strings.TrimSpace(sku) == "" {
+			return nil, fmt.Errorf("sku must be a non-empty string")
+		}
+		item.SKU = strings.TrimSpace(sku)
+		fields = append(fields, "sku")
+	}
+
+	if raw, ok := patch["description"]; ok {
+		var desc string
+		if !isJSONNull(raw) {
+			if err := json.Unmarshal(raw, &desc); err != nil {
+				return nil, fmt.Errorf("description must be a string")
+			}
+		}
+		item.Description = desc
+		fields = append(fields, "description")
+	}
+
+	if raw, ok := patch["price"]; ok {
+		var price float64
+		if isJSONNull(raw) || json.Unmarshal(raw, &price) != nil || price < 0 {
+			return nil, fmt.Errorf("price must be a non-negative number")
+		}
+		item.Price = price
+		fields = append(fields, "price")
+	}
+
+	if raw, ok := patch["cost"]; ok {
+		var cost float64
+		if isJSONNull(raw) || json.Unmarshal(raw, &cost) != nil || cost < 0 {
+			return nil, fmt.Errorf("cost must be a non-negative number")
+		}
+		item.Cost = cost
+		fields = append(fields, "cost")
+	}
+
+	if raw, ok := patch["quantity"]; ok {
+		var qty int
+		if isJSONNull(raw) || json.Unmarshal(raw, &qty) != nil || qty < 0 {
+			return nil, fmt.Errorf("quantity must be a non-negative integer")
+		}
+		item.Quantity = qty
+		fields = append(fields, "quantity")
+	}
+
+	if raw, ok := patch["category_id"]; ok {
+		var categoryID uint
+		if !isJSONNull(raw) {
+			if err := json.Unmarshal(raw, &categoryID); err != nil {
+				return nil, fmt.Errorf("category_id must be a category id or null")
+			}
+		}
+		item.CategoryID = categoryID
+		fields = append(fields, "category_id")
+	}
+
+	if raw, ok := patch["attributes"]; ok {
+		if isJSONNull(raw) {
+			item.Attributes = nil
+		} else {
+			var attrs map[string]interface{}
+			if err := json.Unmarshal(raw, &attrs); err != nil {
+				return nil, fmt.Errorf("attributes must be an object")
+			}
+			item.Attributes = mergePatchObject(item.Attributes, attrs)
+		}
+		fields = append(fields, "attributes")
+	}
+
+	return fields, nil
+}
+
+// PATCH /categories/:id
+func PatchCategory(c *gin.Context) {
+	id := c.Param("id")
+	var category models.Category
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+	userID := c.MustGet("userId").(uint)
+
+	query := db(c).Where("id = ?", id)
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	if err := query.First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && category.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot update this category"})
+		return
+	}
+
+	if !checkIfMatch(c, category.Version, category) {
+		return
+	}
+
+	// parent_id changes go through PUT /categories/:id/move
+	patch, err := readMergePatch(c, "name", "code")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := category
+	var fields []string
+
+	if raw, ok := patch["name"]; ok {
+		var name string
+		if isJSONNull(raw) || json.Unmarshal(raw, &name) != nil || strings.TrimSpace(name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be a non-empty string"})
+			return
+		}
+		category.Name = name
+		fields = append(fields, "name")
+	}
+
+	if raw, ok := patch["code"]; ok {
+		var code string
+		if !isJSONNull(raw) {
+			if err := json.Unmarshal(raw, &code); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "code must be a string"})
+				return
+			}
+		}
+		category.Code = strings.TrimSpace(code)
+		fields = append(fields, "code")
+	}
+
+	if len(fields) == 0 {
+		setETag(c, category.Version)
+		c.JSON(http.StatusOK, category)
+		return
+	}
+
+	err = updateCategoryVersioned(db(c), &category, fields...)
+	if errors.Is(err, errVersionConflict) {
+		var current models.Category
+		db(c).First(&current, category.ID)
+		preconditionFailed(c, current.Version, current)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, category.CompanyID, models.AuditUpdate, entityCategory, category.ID, before, category) {
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, category)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
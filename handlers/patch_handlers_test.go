@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePatchObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		target map[string]interface{}
+		patch  map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "replace value",
+			target: map[string]interface{}{"a": "b"},
+			patch:  map[string]interface{}{"a": "c"},
+			want:   map[string]interface{}{"a": "c"},
+		},
+		{
+			name:   "add member",
+			target: map[string]interface{}{"a": "b"},
+			patch:  map[string]interface{}{"b": "c"},
+			want:   map[string]interface{}{"a": "b", "b": "c"},
+		},
+		{
+			name:   "null removes member",
+			target: map[string]interface{}{"a": "b", "b": "c"},
+			patch:  map[string]interface{}{"a": nil},
+			want:   map[string]interface{}{"b": "c"},
+		},
+		{
+			name:   "null for missing member",
+			target: map[string]interface{}{"a": "b"},
+			patch:  map[string]interface{}{"x": nil},
+			want:   map[string]interface{}{"a": "b"},
+		},
+		{
+			name:   "nested objects merge",
+			target: map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "e"}},
+			patch:  map[string]interface{}{"a": map[string]interface{}{"d": nil, "f": "g"}},
+			want:   map[string]interface{}{"a": map[string]interface{}{"b": "c", "f": "g"}},
+		},
+		{
+			name:   "object replaces scalar",
+			target: map[string]interface{}{"a": "b"},
+			patch:  map[string]interface{}{"a": map[string]interface{}{"c": "d"}},
+			want:   map[string]interface{}{"a": map[string]interface{}{"c": "d"}},
+		},
+		{
+			name:   "array replaces array",
+			target: map[string]interface{}{"a": []interface{}{"b", "c"}},
+			patch:  map[string]interface{}{"a": []interface{}{"d"}},
+			want:   map[string]interface{}{"a": []interface{}{"d"}},
+		},
+		{
+			name:   "nil target",
+			target: nil,
+			patch:  map[string]interface{}{"a": "b", "c": nil},
+			want:   map[string]interface{}{"a": "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergePatchObject(tt.target, tt.patch)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchObjectKeepsTarget(t *testing.T) {
+	target := map[string]interface{}{"a": "b"}
+	mergePatchObject(target, map[string]interface{}{"a": nil, "c": "d"})
+	if !reflect.DeepEqual(target, map[string]interface{}{"a": "b"}) {
+		t.Errorf("target was modified: %v", target)
+	}
+}
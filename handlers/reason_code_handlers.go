@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+)
+
+const entityReasonCode = "reason_code"
+
+// GET /reason-codes
+func GetReasonCodes(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	var codes []models.ReasonCode
+	query := db(c).Where("company_id = ?", companyID).Order("code")
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&codes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// POST /reason-codes
+func CreateReasonCode(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can create reason codes"})
+		return
+	}
+
+	var input models.ReasonCode
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := models.ReasonCode{
+		CompanyID:   companyID,
+		Code:        strings.ToUpper(strings.TrimSpace(input.Code)),
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Active:      true,
+	}
+	if code.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	var existing models.ReasonCode
+	if err := db(c).Where("company_id = ? AND code = ?", companyID, code.Code).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reason code already exists"})
+		return
+	}
+
+	if err := db(c).Create(&code).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, companyID, models.AuditCreate, entityReasonCode, code.ID, nil, code) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+// PUT /reason-codes/:id
+func UpdateReasonCode(c *gin.Context) {
+	code, ok := loadReasonCodeForAdmin(c, "Only admins can update reason codes")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Active      *bool  `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the code itself is fixed once created so reports stay comparable
+	before := *code
+	code.Name = strings.TrimSpace(input.Name)
+	code.Description = input.Description
+	if input.Active != nil {
+		code.Active = *input.Active
+	}
+
+	if err := db(c).Save(code).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, code.CompanyID, models.AuditUpdate, entityReasonCode, code.ID, before, code) {
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+// DELETE /reason-codes/:id
+func DeleteReasonCode(c *gin.Context) {
+	code, ok := loadReasonCodeForAdmin(c, "Only admins can delete reason codes")
+	if !ok {
+		return
+	}
+
+	var used int64
+	if err := db(c).Model(&models.WriteOff{}).Where("reason_code_id = ?", code.ID).Count(&used).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reason code is used by write-offs; deactivate it instead"})
+		return
+	}
+
+	if err := db(c).Delete(code).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, code.CompanyID, models.AuditDelete, entityReasonCode, code.ID, code, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reason code deleted"})
+}
+
+func loadReasonCodeForAdmin(c *gin.Context, forbidden string) (*models.ReasonCode, bool) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": forbidden})
+		return nil, false
+	}
+
+	var code models.ReasonCode
+	if err := db(c).First(&code, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reason code not found"})
+		return nil, false
+	}
+	return &code, true
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Twinemukama/go-inventory-manager/ledger"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const entityReconciliation = "reconciliation"
+
+// POST /reconciliations
+func StartReconciliation(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can reconcile stock"})
+		return
+	}
+
+	run, err := ledger.Reconcile(db(c), companyID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, companyID, models.AuditCreate, entityReconciliation, run.ID, nil, run) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// GET /reconciliations
+func ListReconciliations(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	query := db(c).Where("company_id = ?", companyID).Order("id DESC").Limit(100)
+	if v := c.Query("status"); v != "" {
+		query = query.Where("status = ?", v)
+	}
+
+	var runs []models.ReconciliationRun
+	if err := query.Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GET /reconciliations/:id
+func GetReconciliation(c *gin.Context) {
+	run, ok := loadReconciliation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// POST /reconciliations/:id/approve
+func ApproveReconciliation(c *gin.Context) {
+	reviewReconciliation(c, func(conn *gorm.DB, run *models.ReconciliationRun, userID uint) error {
+		return ledger.Approve(conn, run, userID, func(tx *gorm.DB, item models.Item, t models.Transaction) error {
+			// the quantity is unchanged, but the item's history records who
+			// approved the adjustment
+			if err := saveItemRevisionRef(tx, &item, item, userID, models.AuditAdjust, entityReconciliation, run.ID); err != nil {
+				return err
+			}
+			// stock the ledger was missing may be owed to backorders
+			if t.Type == models.TransactionIn {
+				return allocateBackorders(tx, item.CompanyID, item.ID, userID)
+			}
+			return nil
+		})
+	})
+}
+
+// POST /reconciliations/:id/reject
+func RejectReconciliation(c *gin.Context) {
+	reviewReconciliation(c, ledger.Reject)
+}
+
+func reviewReconciliation(c *gin.Context, review func(*gorm.DB, *models.ReconciliationRun, uint) error) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can review reconciliations"})
+		return
+	}
+
+	run, ok := loadReconciliation(c)
+	if !ok {
+		return
+	}
+
+	before := *run
+	if err := review(db(c), run, userID); err != nil {
+		if errors.Is(err, ledger.ErrNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		respondError(c, err)
+		return
+	}
+
+	if !recordAudit(c, run.CompanyID, models.AuditUpdate, entityReconciliation, run.ID, before, run) {
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func loadReconciliation(c *gin.Context) (*models.ReconciliationRun, bool) {
+	companyID := c.MustGet("companyId").(uint)
+
+	var run models.ReconciliationRun
+	if err := db(c).Preload("Lines").First(&run, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reconciliation not found"})
+		return nil, false
+	}
+	return &run, true
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reportRange holds the from/to/period query params shared by reports.
+// From and To are optional; Period is the date_trunc unit rows are grouped
+// by.
+type reportRange struct {
+	From   *time.Time
+	To     *time.Time
+	Period string
+}
+
+// parseReportRange reads from, to (YYYY-MM-DD or RFC3339, to is inclusive)
+// and period (day, week or month; month by default).
+func parseReportRange(c *gin.Context) (reportRange, error) {
+	r := reportRange{Period: c.DefaultQuery("period", "month")}
+	switch r.Period {
+	case "day", "week", "month":
+	default:
+		return r, fmt.Errorf("period must be day, week or month")
+	}
+
+	if v := c.Query("from"); v != "" {
+		t, err := parseDateParam(v, false)
+		if err != nil {
+			return r, fmt.Errorf("invalid from, expected YYYY-MM-DD or RFC3339")
+		}
+		r.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := parseDateParam(v, true)
+		if err != nil {
+			return r, fmt.Errorf("invalid to, expected YYYY-MM-DD or RFC3339")
+		}
+		r.To = &t
+	}
+	if r.From != nil && r.To != nil && r.To.Before(*r.From) {
+		return r, fmt.Errorf("to must not be before from")
+	}
+	return r, nil
+}
+
+// periodExpr truncates a timestamp column to the report period.
+func (r reportRange) periodExpr(column string) string {
+	return fmt.Sprintf("date_trunc('%s', %s)", r.Period, column)
+}
+
+// apply restricts query to rows whose column falls within the range.
+func (r reportRange) apply(query *gorm.DB, column string) *gorm.DB {
+	if r.From != nil {
+		query = query.Where(column+" >= ?", *r.From)
+	}
+	if r.To != nil {
+		query = query.Where(column+" <= ?", *r.To)
+	}
+	return query
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseReportRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	day := func(s string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return t
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantPeriod string
+		wantFrom   *time.Time
+		wantTo     *time.Time
+		wantErr    string
+	}{
+		{name: "defaults", wantPeriod: "month"},
+		{name: "week", query: "period=week", wantPeriod: "week"},
+		{name: "bad period", query: "period=year", wantErr: "period must be"},
+		{
+			name:       "dates, to inclusive",
+			query:      "from=2026-01-01&to=2026-01-31&period=day",
+			wantPeriod: "day",
+			wantFrom:   ptr(day("2026-01-01")),
+			wantTo:     ptr(day("2026-02-01").Add(-time.Nanosecond)),
+		},
+		{
+			name:       "rfc3339",
+			query:      "from=2026-01-01T10:00:00Z",
+			wantPeriod: "month",
+			wantFrom:   ptr(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:       "same day",
+			query:      "from=2026-01-01&to=2026-01-01",
+			wantPeriod: "month",
+			wantFrom:   ptr(day("2026-01-01")),
+			wantTo:     ptr(day("2026-01-02").Add(-time.Nanosecond)),
+		},
+		{name: "bad from", query: "from=01/01/2026", wantErr: "invalid from"},
+		{name: "bad to", query: "to=tomorrow", wantErr: "invalid to"},
+		{name: "to before from", query: "from=2026-02-01&to=2026-01-01", wantErr: "must not be before"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/reports?"+tt.query, nil)
+
+			r, err := parseReportRange(c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Period != tt.wantPeriod {
+				t.Errorf("period = %q, want %q", r.Period, tt.wantPeriod)
+			}
+			if !sameTime(r.From, tt.wantFrom) {
+				t.Errorf("from = %v, want %v", r.From, tt.wantFrom)
+			}
+			if !sameTime(r.To, tt.wantTo) {
+				t.Errorf("to = %v, want %v", r.To, tt.wantTo)
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time { return &t }
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/Twinemukama/go-inventory-manager/database"
 	"github.com/Twinemukama/go-inventory-manager/models"
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +13,7 @@ func FetchPendingRequests(c *gin.Context) {
 	companyID := c.MustGet("companyId").(uint)
 
 	var pendingRequests []models.PendingRequest
-	query := database.DB.Where("status = ?", "pending")
+	query := db(c).Where("status = ?", "pending")
 	// non-super admins should only see requests for their company
 	if role != "super_admin" {
 		query = query.Where("target_id = ?", companyID)
@@ -46,7 +45,7 @@ func RespondToRequest(c *gin.Context) {
 
 	// load the request
 	var req models.PendingRequest
-	if err := database.DB.First(&req, id).Error; err != nil {
+	if err := db(c).First(&req, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
 		return
 	}
@@ -69,20 +68,28 @@ func RespondToRequest(c *gin.Context) {
 	var processedUser *models.User
 	if body.Status == "accepted" {
 		var u models.User
-		if err := database.DB.First(&u, req.UserID).Error; err == nil {
+		if err := db(c).First(&u, req.UserID).Error; err == nil {
 			u.Verified = true
-			if err := database.DB.Save(&u).Error; err == nil {
+			if err := db(c).Save(&u).Error; err == nil {
 				processedUser = &u
 			}
 		}
 	}
 
 	// delete the pending request after processing
-	if err := database.DB.Delete(&req).Error; err != nil {
+	if err := db(c).Delete(&req).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pending request"})
 		return
 	}
 
+	after := gin.H{"status": body.Status}
+	if processedUser != nil {
+		after["verified_user_id"] = processedUser.ID
+	}
+	if !recordAudit(c, req.TargetID, models.AuditRespond, entityPendingRequest, req.ID, req, after) {
+		return
+	}
+
 	resp := gin.H{"message": "Request processed", "status": body.Status}
 	if processedUser != nil {
 		resp["user"] = processedUser
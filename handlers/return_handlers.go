@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const entityReturn = "return_authorization"
+
+// POST /returns
+func CreateReturn(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	companyID := c.MustGet("companyId").(uint)
+
+	var body struct {
+		OrderReference string `json:"order_reference"`
+		CustomerName   string `json:"customer_name"`
+		Reason         string `json:"reason"`
+		Lines          []struct {
+			ItemID   uint `json:"item_id" binding:"required"`
+			Quantity int  `json:"quantity" binding:"required"`
+		} `json:"lines" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(body.Lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A return needs at least one line"})
+		return
+	}
+
+	rma := models.ReturnAuthorization{
+		CompanyID:      companyID,
+		OrderReference: body.OrderReference,
+		CustomerName:   body.CustomerName,
+		Reason:         body.Reason,
+		Status:         models.ReturnOpen,
+		UserID:         userID,
+	}
+	for _, l := range body.Lines {
+		if l.Quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Line quantities must be positive"})
+			return
+		}
+		var item models.Item
+		if err := db(c).First(&item, "id = ? AND company_id = ?", l.ItemID, companyID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d not found", l.ItemID)})
+			return
+		}
+		rma.Lines = append(rma.Lines, models.ReturnLine{ItemID: l.ItemID, Quantity: l.Quantity})
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rma).Error; err != nil {
+			return err
+		}
+		rma.Number = fmt.Sprintf("RMA-%06d", rma.ID)
+		return tx.Model(&rma).Update("number", rma.Number).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, companyID, models.AuditCreate, entityReturn, rma.ID, nil, rma) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, rma)
+}
+
+// GET /returns
+func ListReturns(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var returns []models.ReturnAuthorization
+
+	query := db(c).Preload("Lines").Order("id DESC")
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&returns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// GET /returns/:id
+func GetReturn(c *gin.Context) {
+	rma, ok := loadReturn(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, rma)
+}
+
+// POST /returns/:id/lines/:lineId/inspect
+//
+// Records the inspection outcome of a returned line. Restocked goods are
+// posted back to stock with an IN transaction and quarantined goods are
+// held. Scrapped goods are received into quarantine and written off at once
+// with the scrap reason code, so their value shows up as shrinkage.
+func InspectReturnLine(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can disposition returns"})
+		return
+	}
+
+	rma, ok := loadReturn(c)
+	if !ok {
+		return
+	}
+	if rma.Status != models.ReturnOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Return is " + rma.Status})
+		return
+	}
+
+	lineID, err := strconv.Atoi(c.Param("lineId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid line ID"})
+		return
+	}
+
+	var line *models.ReturnLine
+	for i := range rma.Lines {
+		if rma.Lines[i].ID == uint(lineID) {
+			line = &rma.Lines[i]
+		}
+	}
+	if line == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return line not found"})
+		return
+	}
+	if line.Disposition != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Line has already been inspected"})
+		return
+	}
+
+	var body struct {
+		Disposition  string  `json:"disposition" binding:"required"`
+		Note         string  `json:"note"`
+		RefundAmount float64 `json:"refund_amount"`
+		CreditAmount float64 `json:"credit_amount"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch body.Disposition {
+	case models.DispositionRestock, models.DispositionQuarantine, models.DispositionScrap:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "disposition must be restock, quarantine or scrap"})
+		return
+	}
+	if body.RefundAmount < 0 || body.CreditAmount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refund and credit amounts cannot be negative"})
+		return
+	}
+
+	var before models.ReturnAuthorization
+	now := time.Now()
+
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		// re-read the return and its lines under lock so two inspections of
+		// the same line cannot both post stock
+		if err := lockReturn(tx, rma); err != nil {
+			return err
+		}
+		if rma.Status != models.ReturnOpen {
+			return conflict("Return is %s", rma.Status)
+		}
+		line = nil
+		for i := range rma.Lines {
+			if rma.Lines[i].ID == uint(lineID) {
+				line = &rma.Lines[i]
+			}
+		}
+		if line == nil {
+			return conflict("Return line not found")
+		}
+		if line.Disposition != "" {
+			return conflict("Line has already been inspected")
+		}
+		before = *rma
+		before.Lines = append([]models.ReturnLine(nil), rma.Lines...)
+
+		// restocked goods are sellable again; quarantined goods are back on
+		// hand but held until they are checked
+		m := stockMovement{
+			ItemID:        line.ItemID,
+			CompanyID:     rma.CompanyID,
+			Type:          models.TransactionIn,
+			Quantity:      line.Quantity,
+			Note:          fmt.Sprintf("Restocked from %s", rma.Number),
+			UserID:        userID,
+			ReferenceType: entityReturn,
+			ReferenceID:   rma.ID,
+		}
+		if body.Disposition != models.DispositionRestock {
+			m.Status = models.StockQuarantined
+			m.Note = fmt.Sprintf("Quarantined from %s", rma.Number)
+		}
+		t, err := postStockMovement(tx, m)
+		if err != nil {
+			return err
+		}
+		line.TransactionID = &t.ID
+
+		if body.Disposition == models.DispositionScrap {
+			reason, err := scrapReasonCode(tx, rma.CompanyID)
+			if err != nil {
+				return err
+			}
+			var item models.Item
+			if err := tx.First(&item, line.ItemID).Error; err != nil {
+				return err
+			}
+			writeOff := models.WriteOff{
+				CompanyID:    rma.CompanyID,
+				ItemID:       line.ItemID,
+				CategoryID:   item.CategoryID,
+				ReasonCodeID: reason.ID,
+				Status:       models.StockQuarantined,
+				Quantity:     line.Quantity,
+				Note:         fmt.Sprintf("Scrapped from %s", rma.Number),
+				UserID:       userID,
+			}
+			if err := postWriteOff(tx, &writeOff, reason); err != nil {
+				return err
+			}
+		}
+
+		line.Disposition = body.Disposition
+		line.InspectionNote = body.Note
+		line.RefundAmount = body.RefundAmount
+		line.CreditAmount = body.CreditAmount
+		line.InspectedBy = userID
+		line.InspectedAt = &now
+		if err := tx.Save(line).Error; err != nil {
+			return err
+		}
+
+		rma.RefundTotal, rma.CreditTotal = 0, 0
+		done := true
+		for _, l := range rma.Lines {
+			rma.RefundTotal += l.RefundAmount
+			rma.CreditTotal += l.CreditAmount
+			done = done && l.Disposition != ""
+		}
+		if done {
+			rma.Status = models.ReturnCompleted
+		}
+		return tx.Model(rma).Updates(map[string]interface{}{
+			"refund_total": rma.RefundTotal,
+			"credit_total": rma.CreditTotal,
+			"status":       rma.Status,
+		}).Error
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !recordAudit(c, rma.CompanyID, models.AuditUpdate, entityReturn, rma.ID, before, rma) {
+		return
+	}
+
+	c.JSON(http.StatusOK, rma)
+}
+
+// POST /returns/:id/cancel
+func CancelReturn(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can cancel returns"})
+		return
+	}
+
+	rma, ok := loadReturn(c)
+	if !ok {
+		return
+	}
+
+	var before models.ReturnAuthorization
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		// locked like InspectReturnLine so a line cannot be inspected while
+		// the return is being cancelled
+		if err := lockReturn(tx, rma); err != nil {
+			return err
+		}
+		if rma.Status != models.ReturnOpen {
+			return conflict("Return is %s", rma.Status)
+		}
+		for _, l := range rma.Lines {
+			if l.Disposition != "" {
+				return conflict("Returns with inspected lines cannot be cancelled")
+			}
+		}
+
+		before = *rma
+		rma.Status = models.ReturnCancelled
+		return tx.Model(rma).Update("status", rma.Status).Error
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !recordAudit(c, rma.CompanyID, models.AuditUpdate, entityReturn, rma.ID, before, rma) {
+		return
+	}
+
+	c.JSON(http.StatusOK, rma)
+}
+
+func loadReturn(c *gin.Context) (*models.ReturnAuthorization, bool) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var rma models.ReturnAuthorization
+	query := db(c).Preload("Lines", func(q *gorm.DB) *gorm.DB { return q.Order("id") }).Where("id = ?", c.Param("id"))
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&rma).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+		return nil, false
+	}
+	return &rma, true
+}
+
+// lockReturn reloads a return and its lines with row locks held until the
+// end of the transaction.
+func lockReturn(tx *gorm.DB, rma *models.ReturnAuthorization) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(rma, rma.ID).Error; err != nil {
+		return err
+	}
+	var lines []models.ReturnLine
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("return_authorization_id = ?", rma.ID).Order("id").Find(&lines).Error; err != nil {
+		return err
+	}
+	rma.Lines = lines
+	return nil
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// revisionFields are the item fields tracked in revisions, in display order.
+var revisionFields = []string{"name", "sku", "description", "quantity", "price", "cost", "category_id", "attributes"}
+
+// itemRevisionSnapshot returns the tracked fields of an item in the same
+// JSON-normalized form they have once loaded back from the database.
+func itemRevisionSnapshot(item models.Item) map[string]interface{} {
+	raw, _ := json.Marshal(map[string]interface{}{
+		"name":        item.Name,
+		"sku":         item.SKU,
+		"description": item.Description,
+		"quantity":    item.Quantity,
+		"price":       item.Price,
+		"cost":        item.Cost,
+		"category_id": item.CategoryID,
+		"attributes":  item.Attributes,
+	})
+	var m map[string]interface{}
+	json.Unmarshal(raw, &m)
+	return m
+}
+
+// diffSnapshots lists the tracked fields that differ between two snapshots.
+func diffSnapshots(before, after map[string]interface{}) []models.FieldChange {
+	changes := []models.FieldChange{}
+	for _, f := range revisionFields {
+		if !reflect.DeepEqual(before[f], after[f]) {
+			changes = append(changes, models.FieldChange{Field: f, From: before[f], To: after[f]})
+		}
+	}
+	return changes
+}
+
+// saveItemRevision records the state of an item after a change. Items that
+// predate revision tracking first get a baseline revision of their previous
+// state so the first diff is not lost.
+func saveItemRevision(tx *gorm.DB, before *models.Item, after models.Item, userID uint, action string) error {
+	return saveItemRevisionRef(tx, before, after, userID, action, "", 0)
+}
+
+// saveItemRevisionRef is saveItemRevision for changes caused by something
+// other than an edit of the item, such as a stock movement. The revision
+// names its cause and is kept even when no tracked field changed.
+func saveItemRevisionRef(tx *gorm.DB, before *models.Item, after models.Item, userID uint, action, refType string, refID uint) error {
+	var last models.ItemRevision
+	if err := tx.Where("item_id = ?", after.ID).Order("revision DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+
+	if last.ID == 0 && before != nil {
+		last = models.ItemRevision{
+			ItemID:    before.ID,
+			Revision:  1,
+			CompanyID: before.CompanyID,
+			UserID:    before.UserID,
+			Action:    models.AuditCreate,
+			Snapshot:  itemRevisionSnapshot(*before),
+			Changes:   []models.FieldChange{},
+		}
+		if err := tx.Create(&last).Error; err != nil {
+			return err
+		}
+	}
+
+	snapshot := itemRevisionSnapshot(after)
+	changes := []models.FieldChange{}
+	if last.ID != 0 {
+		changes = diffSnapshots(last.Snapshot, snapshot)
+		if len(changes) == 0 && refType == "" {
+			return nil
+		}
+	}
+
+	rev := models.ItemRevision{
+		ItemID:    after.ID,
+		Revision:  last.Revision + 1,
+		CompanyID: after.CompanyID,
+		UserID:    userID,
+		Action:    action,
+		Snapshot:  snapshot,
+		Changes:   changes,
+
+		ReferenceType: refType,
+		ReferenceID:   refID,
+	}
+	return tx.Create(&rev).Error
+}
+
+// GET /items/:id/history
+func GetItemHistory(c *gin.Context) {
+	id := c.Param("id")
+	var item models.Item
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	query := db(c).Unscoped().Where("id = ?", id)
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	var revisions []models.ItemRevision
+	if err := db(c).Where("item_id = ?", item.ID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"item_id": item.ID, "revisions": revisions})
+}
+
+// POST /items/:id/revert
+//
+// Reverts the descriptive fields of an item to a previous revision. Stock
+// (quantity) and the SKU are left untouched.
+func RevertItem(c *gin.Context) {
+	id := c.Param("id")
+	var item models.Item
+
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if err := db(c).First(&item, "id = ? AND company_id = ?", id, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this item"})
+		return
+	}
+
+	var body struct {
+		Revision int `json:"revision" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rev models.ItemRevision
+	if err := db(c).First(&rev, "item_id = ? AND revision = ?", item.ID, body.Revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	before := item
+	snap := rev.Snapshot
+	if v, ok := snap["name"].(string); ok {
+		item.Name = v
+	}
+	if v, ok := snap["description"].(string); ok {
+		item.Description = v
+	}
+	if v, ok := snap["price"].(float64); ok {
+		item.Price = v
+	}
+	if v, ok := snap["cost"].(float64); ok {
+		item.Cost = v
+	}
+	if v, ok := snap["category_id"].(float64); ok {
+		item.CategoryID = uint(v)
+	}
+	attrs, _ := snap["attributes"].(map[string]interface{})
+
+	// the category's attribute definitions may have changed since
+	attrs, err := validateItemAttributes(db(c), item.CompanyID, item.CategoryID, attrs)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot revert: " + err.Error()})
+		return
+	}
+	item.Attributes = attrs
+
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		if err := updateItemVersioned(tx, &item, "name", "description", "price", "cost", "category_id", "attributes"); err != nil {
+			return err
+		}
+		return saveItemRevision(tx, &before, item, userID, models.AuditRevert)
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondItemConflict(c, item.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, item.CompanyID, models.AuditRevert, entityItem, item.ID, before, item) {
+		return
+	}
+
+	setETag(c, item.Version)
+	c.JSON(http.StatusOK, item)
+}
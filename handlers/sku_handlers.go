@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultSKUSetting is used for companies that have not configured SKU
+// generation yet.
+func defaultSKUSetting(companyID uint) models.SKUSetting {
+	return models.SKUSetting{
+		CompanyID:       companyID,
+		Prefix:          "ITM",
+		IncludeCategory: true,
+		Separator:       "-",
+		Padding:         5,
+		NextSequence:    1,
+	}
+}
+
+// GET /sku-settings
+func GetSKUSettings(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	setting := defaultSKUSetting(companyID)
+	if err := db(c).Where("company_id = ?", companyID).First(&setting).Error; err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// PUT /sku-settings
+func UpdateSKUSettings(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can change SKU settings"})
+		return
+	}
+
+	var input models.SKUSetting
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Padding < 1 || input.Padding > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "padding must be between 1 and 12"})
+		return
+	}
+	if input.NextSequence < 1 {
+		input.NextSequence = 1
+	}
+
+	setting := defaultSKUSetting(companyID)
+	if err := db(c).Where("company_id = ?", companyID).First(&setting).Error; err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := setting
+	setting.Prefix = strings.TrimSpace(input.Prefix)
+	setting.IncludeCategory = input.IncludeCategory
+	setting.Separator = input.Separator
+	setting.Padding = input.Padding
+	setting.NextSequence = input.NextSequence
+
+	if err := db(c).Save(&setting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, companyID, models.AuditUpdate, entitySKUSetting, setting.ID, before, setting) {
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// generateSKU builds the next SKU for a company from its SKU settings. It
+// must run inside tx so the sequence row stays locked until the item using
+// the SKU is created.
+func generateSKU(tx *gorm.DB, companyID, categoryID uint) (string, error) {
+	def := defaultSKUSetting(companyID)
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&def).Error; err != nil {
+		return "", err
+	}
+
+	var setting models.SKUSetting
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("company_id = ?", companyID).First(&setting).Error; err != nil {
+		return "", err
+	}
+
+	var parts []string
+	if setting.Prefix != "" {
+		parts = append(parts, setting.Prefix)
+	}
+	if setting.IncludeCategory {
+		code := "GEN"
+		if categoryID != 0 {
+			var category models.Category
+			if err := tx.First(&category, "id = ? AND company_id = ?", categoryID, companyID).Error; err == nil {
+				code = categoryCode(category)
+			}
+		}
+		parts = append(parts, code)
+	}
+
+	// skip over sequence numbers already taken by manually entered SKUs
+	for {
+		seq := fmt.Sprintf("%0*d", setting.Padding, setting.NextSequence)
+		sku := strings.Join(append(parts, seq), setting.Separator)
+		setting.NextSequence++
+
+		var count int64
+		if err := tx.Model(&models.Item{}).Where("company_id = ? AND sku = ?", companyID, sku).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			if err := tx.Model(&setting).Update("next_sequence", setting.NextSequence).Error; err != nil {
+				return "", err
+			}
+			return sku, nil
+		}
+	}
+}
+
+// categoryCode returns the category's code, or the first three letters or
+// digits of its name when no code has been set.
+func categoryCode(category models.Category) string {
+	if category.Code != "" {
+		return strings.ToUpper(category.Code)
+	}
+	var code []rune
+	for _, r := range strings.ToUpper(category.Name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			code = append(code, r)
+			if len(code) >= 3 {
+				break
+			}
+		}
+	}
+	if len(code) == 0 {
+		return "GEN"
+	}
+	return string(code)
+}
+
+// skuTaken reports whether another item in the company already uses sku.
+func skuTaken(conn *gorm.DB, companyID uint, sku string, exceptID uint) (bool, error) {
+	var count int64
+	err := conn.Model(&models.Item{}).
+		Where("company_id = ? AND sku = ? AND id <> ?", companyID, sku, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// isUniqueViolation reports whether err came from a unique constraint, such
+// as two concurrent creates racing for the same SKU.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
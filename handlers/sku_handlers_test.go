@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+)
+
+func TestCategoryCode(t *testing.T) {
+	tests := []struct {
+		category models.Category
+		want     string
+	}{
+		{models.Category{Name: "Electronics"}, "ELE"},
+		{models.Category{Name: "electronics", Code: "elx"}, "ELX"},
+		{models.Category{Name: "A-1 b"}, "A1B"},
+		{models.Category{Name: "Öl"}, "ÖL"},
+		{models.Category{Name: "Ölfilter"}, "ÖLF"},
+		{models.Category{Name: "日本茶セット"}, "日本茶"},
+		{models.Category{Name: "--"}, "GEN"},
+		{models.Category{}, "GEN"},
+	}
+	for _, tt := range tests {
+		if got := categoryCode(tt.category); got != tt.want {
+			t.Errorf("categoryCode(%q) = %q, want %q", tt.category.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockMovement describes a change to an item's stock recorded in the
+// transaction ledger.
+type stockMovement struct {
+	ItemID        uint
+	CompanyID     uint
+	Type          models.TransactionType
+	Status        string // stock status the quantity enters or leaves; empty means available
+	Quantity      int
+	Note          string
+	UserID        uint
+	ReferenceType string
+	ReferenceID   uint
+}
+
+// postStockMovement records a ledger transaction and applies it to the
+// item's cached quantity, and to the status bucket it names, in the same
+// database transaction. OUT movements may not take more than the status
+// holds; IN movements of available stock are offered to open backorders of
+// the item.
+func postStockMovement(tx *gorm.DB, m stockMovement) (*models.Transaction, error) {
+	if m.Quantity <= 0 {
+		return nil, badRequest("quantity must be positive")
+	}
+
+	var item models.Item
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&item, "id = ? AND company_id = ?", m.ItemID, m.CompanyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, badRequest("item %d not found", m.ItemID)
+		}
+		return nil, err
+	}
+
+	if m.Status == models.StockAvailable {
+		m.Status = ""
+	}
+	if m.Status != "" && !isHeldStatus(m.Status) {
+		return nil, badRequest("unknown stock status %q", m.Status)
+	}
+
+	delta := m.Quantity
+	if m.Type == models.TransactionOut {
+		have, err := stockInStatus(tx, item, m.Status)
+		if err != nil {
+			return nil, err
+		}
+		if have < m.Quantity {
+			return nil, badRequest("insufficient %s stock for item %d: %d on hand", statusName(m.Status), item.ID, have)
+		}
+		delta = -m.Quantity
+	}
+
+	if m.Status != "" {
+		if err := adjustHeldStock(tx, m.CompanyID, m.ItemID, m.Status, delta); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&item).Updates(map[string]interface{}{
+		"quantity": gorm.Expr("quantity + ?", delta),
+		"version":  gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	// a revision per movement keeps later edits from being blamed for it
+	after := item
+	after.Quantity += delta
+	after.Version++
+	if err := saveItemRevisionRef(tx, &item, after, m.UserID, models.AuditAdjust, m.ReferenceType, m.ReferenceID); err != nil {
+		return nil, err
+	}
+
+	t := models.Transaction{
+		ItemID:        m.ItemID,
+		CompanyID:     m.CompanyID,
+		Quantity:      m.Quantity,
+		Type:          m.Type,
+		Status:        m.Status,
+		UnitCost:      item.Cost,
+		Note:          m.Note,
+		UserID:        m.UserID,
+		ReferenceType: m.ReferenceType,
+		ReferenceID:   m.ReferenceID,
+	}
+	if err := tx.Create(&t).Error; err != nil {
+		return nil, err
+	}
+
+	// incoming stock goes to waiting backorders first
+	if m.Type == models.TransactionIn && m.Status == "" {
+		if err := allocateBackorders(tx, m.CompanyID, m.ItemID, m.UserID); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// moveStockStatus shifts quantity of an item from one status to another.
+// The quantity on hand is unchanged.
+func moveStockStatus(tx *gorm.DB, move *models.StockStatusMove) error {
+	if move.Quantity <= 0 {
+		return badRequest("quantity must be positive")
+	}
+	if move.FromStatus == move.ToStatus {
+		return badRequest("from and to statuses must differ")
+	}
+	for _, s := range []string{move.FromStatus, move.ToStatus} {
+		if s != models.StockAvailable && !isHeldStatus(s) {
+			return badRequest("unknown stock status %q", s)
+		}
+	}
+
+	var item models.Item
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&item, "id = ? AND company_id = ?", move.ItemID, move.CompanyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return badRequest("item %d not found", move.ItemID)
+		}
+		return err
+	}
+
+	have, err := stockInStatus(tx, item, move.FromStatus)
+	if err != nil {
+		return err
+	}
+	if have < move.Quantity {
+		return badRequest("insufficient %s stock for item %d: %d on hand", move.FromStatus, item.ID, have)
+	}
+
+	if isHeldStatus(move.FromStatus) {
+		if err := adjustHeldStock(tx, move.CompanyID, move.ItemID, move.FromStatus, -move.Quantity); err != nil {
+			return err
+		}
+	}
+	if isHeldStatus(move.ToStatus) {
+		if err := adjustHeldStock(tx, move.CompanyID, move.ItemID, move.ToStatus, move.Quantity); err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(move).Error; err != nil {
+		return err
+	}
+
+	// released stock may satisfy waiting backorders
+	if move.ToStatus == models.StockAvailable {
+		return allocateBackorders(tx, move.CompanyID, move.ItemID, move.UserID)
+	}
+	return nil
+}
+
+// adjustHeldStock adds delta to an item's bucket for a held status.
+func adjustHeldStock(tx *gorm.DB, companyID, itemID uint, status string, delta int) error {
+	bucket := models.ItemStock{CompanyID: companyID, ItemID: itemID, Status: status}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("item_id = ? AND status = ?", itemID, status).FirstOrCreate(&bucket).Error; err != nil {
+		return err
+	}
+	if bucket.Quantity+delta < 0 {
+		return badRequest("insufficient %s stock for item %d: %d on hand", status, itemID, bucket.Quantity)
+	}
+	return tx.Model(&bucket).Update("quantity", gorm.Expr("quantity + ?", delta)).Error
+}
+
+// stockInStatus returns how much of an item is in the given status. An
+// empty status means available.
+func stockInStatus(conn *gorm.DB, item models.Item, status string) (int, error) {
+	if status == "" || status == models.StockAvailable {
+		held, err := heldStock(conn, item.ID)
+		if err != nil {
+			return 0, err
+		}
+		return item.Quantity - held, nil
+	}
+
+	var qty int
+	err := conn.Model(&models.ItemStock{}).
+		Where("item_id = ? AND status = ?", item.ID, status).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&qty).Error
+	return qty, err
+}
+
+// heldStock returns the quantity of an item in non-sellable statuses.
+func heldStock(conn *gorm.DB, itemID uint) (int, error) {
+	var qty int
+	err := conn.Model(&models.ItemStock{}).Where("item_id = ?", itemID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&qty).Error
+	return qty, err
+}
+
+// fillAvailable sets the Available field of the given items.
+func fillAvailable(conn *gorm.DB, items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	var rows []struct {
+		ItemID uint
+		Held   int
+	}
+	if err := conn.Model(&models.ItemStock{}).Select("item_id, SUM(quantity) AS held").
+		Where("item_id IN ?", ids).Group("item_id").Scan(&rows).Error; err != nil {
+		return err
+	}
+	held := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		held[r.ItemID] = r.Held
+	}
+	for i := range items {
+		items[i].Available = items[i].Quantity - held[items[i].ID]
+	}
+	return nil
+}
+
+func isHeldStatus(status string) bool {
+	for _, s := range models.HeldStockStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func statusName(status string) string {
+	if status == "" {
+		return models.StockAvailable
+	}
+	return status
+}
+
+// postQuantityChange records in the ledger a change already written to an
+// item's cached quantity, such as a quantity set through PUT or an item
+// being trashed. Unlike postStockMovement it does not touch the item,
+// except that added stock is offered to open backorders.
+func postQuantityChange(tx *gorm.DB, item models.Item, from, to int, userID uint, reference string) error {
+	if from == to {
+		return nil
+	}
+
+	t := models.Transaction{
+		ItemID:        item.ID,
+		CompanyID:     item.CompanyID,
+		Quantity:      to - from,
+		Type:          models.TransactionIn,
+		UnitCost:      item.Cost,
+		Note:          quantityChangeNote(reference, from, to),
+		UserID:        userID,
+		ReferenceType: reference,
+		ReferenceID:   item.ID,
+	}
+	if to < from {
+		t.Type = models.TransactionOut
+		t.Quantity = from - to
+	}
+	if err := tx.Create(&t).Error; err != nil {
+		return err
+	}
+
+	if t.Type == models.TransactionIn {
+		return allocateBackorders(tx, item.CompanyID, item.ID, userID)
+	}
+	return nil
+}
+
+func quantityChangeNote(reference string, from, to int) string {
+	switch reference {
+	case models.ReferenceOpeningBalance:
+		return "Opening balance"
+	case models.ReferenceItemTrash:
+		return "Item moved to trash"
+	case models.ReferenceItemRestore:
+		return "Item restored from trash"
+	}
+	return fmt.Sprintf("Quantity set from %d to %d", from, to)
+}
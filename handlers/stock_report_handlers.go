@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/ledger"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /reports/stock?as_of=YYYY-MM-DD
+func GetStockReport(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	day, err := time.ParseInLocation("2006-01-02", c.Query("as_of"), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of is required, expected YYYY-MM-DD"})
+		return
+	}
+
+	// the nightly snapshot is used when there is one; otherwise the stock
+	// at the end of the day is replayed from the ledger
+	source := "snapshot"
+	lines, found, err := ledger.SnapshotAsOf(db(c), companyID, day)
+	if err == nil && !found {
+		source = "ledger"
+		lines, err = ledger.StockAsOf(db(c), companyID, day.Add(24*time.Hour-time.Nanosecond))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	totalQuantity, totalValue := 0, 0.0
+	for _, l := range lines {
+		totalQuantity += l.Quantity
+		totalValue += l.Value
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"as_of":  day.Format("2006-01-02"),
+		"source": source,
+		"items":  lines,
+		"total": gin.H{
+			"quantity": totalQuantity,
+			"value":    totalValue,
+		},
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const entityStockMove = "stock_status_move"
+
+// errNoLocations answers requests naming a stock location. Statuses are
+// tracked per item only; there is no location dimension to filter or move by.
+var errNoLocations = errors.New("stock locations are not tracked, location filters are not supported")
+
+// locationRequested reports whether the query names a stock location.
+func locationRequested(c *gin.Context) bool {
+	return c.Query("location") != "" || c.Query("location_id") != ""
+}
+
+// GET /items/:id/stock
+// Stock is not tracked by location, so location and location_id are
+// rejected with 400.
+func GetItemStock(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	if locationRequested(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNoLocations.Error()})
+		return
+	}
+
+	var item models.Item
+	if err := db(c).First(&item, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	var buckets []models.ItemStock
+	if err := db(c).Where("item_id = ?", item.ID).Find(&buckets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	statuses := gin.H{}
+	held := 0
+	for _, s := range models.HeldStockStatuses {
+		statuses[s] = 0
+	}
+	for _, b := range buckets {
+		statuses[b.Status] = b.Quantity
+		held += b.Quantity
+	}
+	statuses[models.StockAvailable] = item.Quantity - held
+
+	c.JSON(http.StatusOK, gin.H{
+		"item_id":  item.ID,
+		"on_hand":  item.Quantity,
+		"statuses": statuses,
+	})
+}
+
+// POST /items/:id/stock/moves
+// Moves shift quantity between statuses of the whole item; a location or
+// location_id in the body is rejected with 400.
+func MoveItemStock(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var item models.Item
+	if err := db(c).First(&item, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to move stock of this item"})
+		return
+	}
+
+	var body struct {
+		From       string `json:"from" binding:"required"`
+		To         string `json:"to" binding:"required"`
+		Quantity   int    `json:"quantity" binding:"required"`
+		Note       string `json:"note"`
+		Location   string `json:"location"`
+		LocationID uint   `json:"location_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Location != "" || body.LocationID != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNoLocations.Error()})
+		return
+	}
+
+	move := models.StockStatusMove{
+		CompanyID:  companyID,
+		ItemID:     item.ID,
+		FromStatus: body.From,
+		ToStatus:   body.To,
+		Quantity:   body.Quantity,
+		Note:       body.Note,
+		UserID:     userID,
+	}
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		return moveStockStatus(tx, &move)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !recordAudit(c, companyID, models.AuditCreate, entityStockMove, move.ID, nil, move) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, move)
+}
+
+// GET /items/:id/stock/moves
+func ListItemStockMoves(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	var moves []models.StockStatusMove
+	if err := db(c).Where("item_id = ? AND company_id = ?", c.Param("id"), companyID).
+		Order("id DESC").Find(&moves).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, moves)
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /tags
+func GetTags(c *gin.Context) {
+	var tags []models.Tag
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	query := db(c).Order("name")
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	if err := query.Find(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// POST /tags
+func CreateTag(c *gin.Context) {
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	companyID := c.MustGet("companyId").(uint)
+	role := c.MustGet("role").(string)
+
+	if role != "super_admin" || tag.CompanyID == 0 {
+		tag.CompanyID = companyID
+	}
+
+	tag.ID = 0
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	var existing models.Tag
+	if err := db(c).Where("company_id = ? AND name = ?", tag.CompanyID, tag.Name).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag already exists"})
+		return
+	}
+
+	if err := db(c).Create(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, tag.CompanyID, models.AuditCreate, entityTag, tag.ID, nil, tag) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// PUT /tags/:id
+func UpdateTag(c *gin.Context) {
+	tag, ok := loadTagForAdmin(c, "Only admins can update tags")
+	if !ok {
+		return
+	}
+
+	var input models.Tag
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	var existing models.Tag
+	if err := db(c).Where("company_id = ? AND name = ? AND id <> ?", tag.CompanyID, input.Name, tag.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag already exists"})
+		return
+	}
+
+	before := *tag
+	tag.Name = input.Name
+	tag.Color = input.Color
+
+	if err := db(c).Save(tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, tag.CompanyID, models.AuditUpdate, entityTag, tag.ID, before, tag) {
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DELETE /tags/:id
+func DeleteTag(c *gin.Context) {
+	tag, ok := loadTagForAdmin(c, "Only admins can delete tags")
+	if !ok {
+		return
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM item_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !recordAudit(c, tag.CompanyID, models.AuditDelete, entityTag, tag.ID, tag, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted"})
+}
+
+// POST /items/:id/tags
+func AddItemTags(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	item, ok := loadItemForTagging(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		TagIDs []uint `json:"tag_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tags []models.Tag
+	if err := db(c).Where("id IN ? AND company_id = ?", body.TagIDs, item.CompanyID).Find(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(tags) != len(uniqueUints(body.TagIDs)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "One or more tags not found"})
+		return
+	}
+
+	before := itemTagSnapshot(db(c), item)
+
+	// each tag gets its own version and revision, as bulk add_tag does
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		for i := range tags {
+			if err := tagItem(tx, item, &tags[i], userID, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondItemConflict(c, item.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	db(c).Preload("Tags").First(item, item.ID)
+	if !recordAudit(c, item.CompanyID, models.AuditUpdate, entityItem, item.ID, before, itemTagSnapshot(db(c), item)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DELETE /items/:id/tags/:tagId
+func RemoveItemTag(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	item, ok := loadItemForTagging(c)
+	if !ok {
+		return
+	}
+
+	var tag models.Tag
+	if err := db(c).First(&tag, "id = ? AND company_id = ?", c.Param("tagId"), item.CompanyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	before := itemTagSnapshot(db(c), item)
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		return tagItem(tx, item, &tag, userID, false)
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondItemConflict(c, item.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	db(c).Preload("Tags").First(item, item.ID)
+	if !recordAudit(c, item.CompanyID, models.AuditUpdate, entityItem, item.ID, before, itemTagSnapshot(db(c), item)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// tagItem attaches a tag to an item or detaches it, bumping the item's
+// version and recording a revision that names the tag.
+func tagItem(tx *gorm.DB, item *models.Item, tag *models.Tag, userID uint, attach bool) error {
+	before := *item
+	var err error
+	if attach {
+		err = tx.Model(item).Association("Tags").Append(tag)
+	} else {
+		err = tx.Model(item).Association("Tags").Delete(tag)
+	}
+	if err != nil {
+		return err
+	}
+	if err := updateItemVersioned(tx, item); err != nil {
+		return err
+	}
+	return saveItemRevisionRef(tx, &before, *item, userID, models.AuditUpdate, entityTag, tag.ID)
+}
+
+func loadTagForAdmin(c *gin.Context, forbidden string) (*models.Tag, bool) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": forbidden})
+		return nil, false
+	}
+
+	var tag models.Tag
+	query := db(c).Where("id = ?", c.Param("id"))
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return nil, false
+	}
+	return &tag, true
+}
+
+// loadItemForTagging applies the same ownership rules as UpdateItem.
+func loadItemForTagging(c *gin.Context) (*models.Item, bool) {
+	var item models.Item
+
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if err := db(c).First(&item, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return nil, false
+	}
+
+	if role != "admin" && role != "super_admin" && item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this item"})
+		return nil, false
+	}
+	return &item, true
+}
+
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// itemTagSnapshot captures an item's tag ids for the audit log.
+func itemTagSnapshot(conn *gorm.DB, item *models.Item) gin.H {
+	var ids []uint
+	conn.Table("item_tags").Where("item_id = ?", item.ID).Order("tag_id").Pluck("tag_id", &ids)
+	return gin.H{"tag_ids": ids}
+}
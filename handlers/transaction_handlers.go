@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+)
+
+// transactionSortColumns whitelists the columns ListTransactions may order by.
+var transactionSortColumns = map[string]keysetColumn{
+	"created_at": {"transactions.created_at", keysetTime},
+	"quantity":   {"transactions.quantity", keysetInt},
+}
+
+// GET /transactions
+func ListTransactions(c *gin.Context) {
+	pg := parsePageParams(c)
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	var transactions []models.Transaction
+	var total int64
+
+	query := db(c).Model(&models.Transaction{})
+
+	if role != "super_admin" {
+		query = query.Where("transactions.company_id = ?", companyID)
+	}
+
+	if v := c.Query("item_id"); v != "" {
+		itemID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item_id"})
+			return
+		}
+		query = query.Where("transactions.item_id = ?", itemID)
+	}
+	if v := c.Query("type"); v != "" {
+		query = query.Where("transactions.type = ?", v)
+	}
+
+	// newest first unless the caller asks otherwise
+	sort, err := parseSort(c.Query("sort"), transactionSortColumns, "transactions.id", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if pg.Keyset {
+		if pg.Cursor != "" {
+			if query, err = sort.after(query, pg.Cursor); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := query.Order(sort.orderClause()).Limit(pg.Limit + 1).Find(&transactions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var nextCursor string
+		if len(transactions) > pg.Limit {
+			transactions = transactions[:pg.Limit]
+			last := transactions[len(transactions)-1]
+			nextCursor = sort.cursorFor(transactionSortValue(last, sort.Key), last.ID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"transactions": transactions,
+			"limit":        pg.Limit,
+			"next_cursor":  nextCursor,
+		})
+		return
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Order(sort.orderClause()).Limit(pg.Limit).Offset(pg.offset()).Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"page":         pg.Page,
+		"limit":        pg.Limit,
+		"total":        total,
+	})
+}
+
+func transactionSortValue(t models.Transaction, key string) interface{} {
+	switch key {
+	case "created_at":
+		return t.CreatedAt
+	case "quantity":
+		return t.Quantity
+	}
+	return nil
+}
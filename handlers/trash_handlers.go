@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /trash
+func GetTrash(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view the trash"})
+		return
+	}
+
+	var items []models.Item
+	var categories []models.Category
+
+	itemQuery := db(c).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+	categoryQuery := db(c).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+	if role != "super_admin" {
+		itemQuery = itemQuery.Where("company_id = ?", companyID)
+		categoryQuery = categoryQuery.Where("company_id = ?", companyID)
+	}
+
+	kind := c.Query("type")
+	if kind == "" || kind == "items" {
+		if err := itemQuery.Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if kind == "" || kind == "categories" {
+		if err := categoryQuery.Find(&categories).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "categories": categories})
+}
+
+// POST /items/:id/restore
+func RestoreItem(c *gin.Context) {
+	id := c.Param("id")
+	var item models.Item
+
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if err := db(c).Unscoped().First(&item, "id = ? AND company_id = ? AND deleted_at IS NOT NULL", id, companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to restore this item"})
+		return
+	}
+
+	// the SKU may have been reused while the item was in the trash
+	if item.SKU != "" {
+		taken, err := skuTaken(db(c), item.CompanyID, item.SKU, item.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another item now uses this SKU"})
+			return
+		}
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&item).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return postQuantityChange(tx, item, 0, item.Quantity, userID, models.ReferenceItemRestore)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// reloaded since restored stock may have gone to waiting backorders
+	db(c).First(&item, item.ID)
+	if !recordAudit(c, item.CompanyID, models.AuditRestore, entityItem, item.ID, nil, item) {
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// POST /categories/:id/restore
+func RestoreCategory(c *gin.Context) {
+	id := c.Param("id")
+	var category models.Category
+
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+	userID := c.MustGet("userId").(uint)
+
+	query := db(c).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id)
+	if role != "super_admin" {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	if err := query.First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found in trash"})
+		return
+	}
+
+	if role != "admin" && role != "super_admin" && category.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot restore this category"})
+		return
+	}
+
+	if category.ParentID != nil {
+		var parent models.Category
+		if err := db(c).First(&parent, *category.ParentID).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Restore the parent category first"})
+			return
+		}
+	}
+
+	if err := db(c).Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	category.DeletedAt.Valid = false
+	if !recordAudit(c, category.CompanyID, models.AuditRestore, entityCategory, category.ID, nil, category) {
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
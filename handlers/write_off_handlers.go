@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const entityWriteOff = "write_off"
+
+// POST /items/:id/write-offs
+func CreateWriteOff(c *gin.Context) {
+	userID := c.MustGet("userId").(uint)
+	role := c.MustGet("role").(string)
+	companyID := c.MustGet("companyId").(uint)
+
+	if role != "admin" && role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can write off stock"})
+		return
+	}
+
+	var item models.Item
+	if err := db(c).First(&item, "id = ? AND company_id = ?", c.Param("id"), companyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	var body struct {
+		ReasonCodeID uint   `json:"reason_code_id" binding:"required"`
+		Quantity     int    `json:"quantity" binding:"required"`
+		Status       string `json:"status"` // defaults to available
+		Note         string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reason models.ReasonCode
+	if err := db(c).First(&reason, "id = ? AND company_id = ? AND active = ?", body.ReasonCodeID, companyID, true).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason code not found or inactive"})
+		return
+	}
+
+	writeOff := models.WriteOff{
+		CompanyID:    companyID,
+		ItemID:       item.ID,
+		CategoryID:   item.CategoryID,
+		ReasonCodeID: reason.ID,
+		Status:       statusName(body.Status),
+		Quantity:     body.Quantity,
+		Note:         body.Note,
+		UserID:       userID,
+	}
+
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		return postWriteOff(tx, &writeOff, reason)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	writeOff.ReasonCode = reason
+	if !recordAudit(c, companyID, models.AuditCreate, entityWriteOff, writeOff.ID, nil, writeOff) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, writeOff)
+}
+
+// postWriteOff records a write-off and takes its quantity out of stock with
+// an OUT transaction, valued at the item's cost.
+func postWriteOff(tx *gorm.DB, writeOff *models.WriteOff, reason models.ReasonCode) error {
+	note := "Write-off " + reason.Code
+	if writeOff.Note != "" {
+		note += ": " + writeOff.Note
+	}
+
+	if err := tx.Create(writeOff).Error; err != nil {
+		return err
+	}
+	t, err := postStockMovement(tx, stockMovement{
+		ItemID:        writeOff.ItemID,
+		CompanyID:     writeOff.CompanyID,
+		Type:          models.TransactionOut,
+		Status:        writeOff.Status,
+		Quantity:      writeOff.Quantity,
+		Note:          note,
+		UserID:        writeOff.UserID,
+		ReferenceType: entityWriteOff,
+		ReferenceID:   writeOff.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	writeOff.TransactionID = t.ID
+	writeOff.UnitCost = t.UnitCost
+	writeOff.Value = t.UnitCost * float64(t.Quantity)
+	return tx.Model(writeOff).Updates(map[string]interface{}{
+		"transaction_id": writeOff.TransactionID,
+		"unit_cost":      writeOff.UnitCost,
+		"value":          writeOff.Value,
+	}).Error
+}
+
+// scrapReasonCode returns the company's reason code for scrapped returns,
+// creating it on first use. It is used even when inactive, since scrapping
+// a return is not a manual write-off.
+func scrapReasonCode(tx *gorm.DB, companyID uint) (models.ReasonCode, error) {
+	reason := models.ReasonCode{CompanyID: companyID, Code: models.ReasonCodeScrap}
+	err := tx.Where(reason).
+		Attrs(models.ReasonCode{Name: "Scrapped", Description: "Returned goods scrapped at inspection", Active: true}).
+		FirstOrCreate(&reason).Error
+	return reason, err
+}
+
+// GET /write-offs
+func ListWriteOffs(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	r, err := parseReportRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := db(c).Preload("ReasonCode").Where("company_id = ?", companyID).Order("id DESC")
+	query = r.apply(query, "created_at")
+	if v := c.Query("item_id"); v != "" {
+		query = query.Where("item_id = ?", v)
+	}
+	if v := c.Query("reason_code_id"); v != "" {
+		query = query.Where("reason_code_id = ?", v)
+	}
+
+	var writeOffs []models.WriteOff
+	if err := query.Find(&writeOffs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, writeOffs)
+}
+
+// shrinkageRow is one group of the shrinkage report.
+type shrinkageRow struct {
+	Key      string  `json:"key"`
+	Label    string  `json:"label"`
+	Count    int     `json:"count"`
+	Quantity int     `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+// GET /reports/shrinkage
+func GetShrinkageReport(c *gin.Context) {
+	companyID := c.MustGet("companyId").(uint)
+
+	r, err := parseReportRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	base := func() *gorm.DB {
+		return r.apply(db(c).Table("write_offs").Where("write_offs.company_id = ?", companyID), "write_offs.created_at")
+	}
+	const sums = "COUNT(*) AS count, COALESCE(SUM(write_offs.quantity), 0) AS quantity, COALESCE(SUM(write_offs.value), 0) AS value"
+
+	var byReason, byCategory, byPeriod []shrinkageRow
+	var total shrinkageRow
+
+	err = base().
+		Select("reason_codes.code AS key, reason_codes.name AS label, " + sums).
+		Joins("JOIN reason_codes ON reason_codes.id = write_offs.reason_code_id").
+		Group("reason_codes.code, reason_codes.name").Order("value DESC").
+		Scan(&byReason).Error
+	if err == nil {
+		// categories may since have been deleted; the id is still reported
+		err = base().
+			Select("write_offs.category_id::text AS key, COALESCE(categories.name, '') AS label, " + sums).
+			Joins("LEFT JOIN categories ON categories.id = write_offs.category_id").
+			Group("write_offs.category_id, categories.name").Order("value DESC").
+			Scan(&byCategory).Error
+	}
+	if err == nil {
+		period := r.periodExpr("write_offs.created_at")
+		err = base().
+			Select("to_char(" + period + ", 'YYYY-MM-DD') AS key, to_char(" + period + ", 'YYYY-MM-DD') AS label, " + sums).
+			Group(period).Order(period).
+			Scan(&byPeriod).Error
+	}
+	if err == nil {
+		err = base().Select(sums).Scan(&total).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":        r.From,
+		"to":          r.To,
+		"period":      r.Period,
+		"by_reason":   byReason,
+		"by_category": byCategory,
+		"by_period":   byPeriod,
+		"total": gin.H{
+			"count":    total.Count,
+			"quantity": total.Quantity,
+			"value":    total.Value,
+		},
+		"generated_at": time.Now(),
+	})
+}
@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/middlewares"
+	"github.com/Twinemukama/go-inventory-manager/models"
+)
+
+// StartIdempotencyCleanup removes stored idempotent responses once they are
+// past their retention window, hourly.
+func StartIdempotencyCleanup() {
+	go func() {
+		for {
+			cutoff := time.Now().Add(-middlewares.IdempotencyTTL())
+			if err := database.DB.Where("created_at < ?", cutoff).Delete(&models.IdempotencyKey{}).Error; err != nil {
+				log.Println("Idempotency key cleanup failed:", err)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/Twinemukama/go-inventory-manager/storage"
+	"gorm.io/gorm"
+)
+
+// defaultTrashRetentionDays is how long trashed records are kept when
+// TRASH_RETENTION_DAYS is not set.
+const defaultTrashRetentionDays = 30
+
+// StartTrashPurge permanently removes trashed items and categories older than
+// the retention period, once at startup and then daily.
+func StartTrashPurge() {
+	days := defaultTrashRetentionDays
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			days = n
+		}
+	}
+	retention := time.Duration(days) * 24 * time.Hour
+
+	go func() {
+		for {
+			items, categories, err := PurgeTrash(time.Now().Add(-retention))
+			if err != nil {
+				log.Println("Trash purge failed:", err)
+			} else if items+categories > 0 {
+				log.Printf("Purged %d items and %d categories from trash", items, categories)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
+
+// PurgeTrash permanently deletes items and categories that were trashed
+// before cutoff, together with their tags, revisions and uploaded files.
+func PurgeTrash(cutoff time.Time) (int, int, error) {
+	var items []models.Item
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&items).Error; err != nil {
+		return 0, 0, err
+	}
+
+	purgedItems := 0
+	for _, item := range items {
+		var attachments []models.ItemAttachment
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("item_id = ?", item.ID).Find(&attachments).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemAttachment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM item_tags WHERE item_id = ?", item.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemRevision{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemStock{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&item).Error
+		})
+		if err != nil {
+			return purgedItems, 0, err
+		}
+
+		// files are removed only once the rows referencing them are gone
+		for _, a := range attachments {
+			storage.Files.Delete(a.StorageKey)
+			if a.ThumbnailKey != "" {
+				storage.Files.Delete(a.ThumbnailKey)
+			}
+		}
+		purgedItems++
+	}
+
+	// purge deepest categories first so parents never outlive their children
+	var categories []models.Category
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("length(path) DESC").Find(&categories).Error; err != nil {
+		return purgedItems, 0, err
+	}
+
+	purgedCategories := 0
+	for _, category := range categories {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("category_id = ?", category.ID).Delete(&models.CategoryAttribute{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&category).Error
+		})
+		if err != nil {
+			return purgedItems, purgedCategories, err
+		}
+		purgedCategories++
+	}
+
+	return purgedItems, purgedCategories, nil
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/ledger"
+	"github.com/Twinemukama/go-inventory-manager/models"
+)
+
+// StartReconciliation checks every company's cached item quantities against
+// the transaction ledger, once at startup and then daily. Mismatches are
+// recorded as a pending run and the company admins are notified.
+func StartReconciliation() {
+	go func() {
+		for {
+			if err := ReconcileAll(); err != nil {
+				log.Println("Ledger reconciliation failed:", err)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
+
+// ReconcileAll starts a run for each company that has no run awaiting
+// review.
+func ReconcileAll() error {
+	var companyIDs []uint
+	err := database.DB.Model(&models.Company{}).
+		Where("id NOT IN (?)", database.DB.Model(&models.ReconciliationRun{}).
+			Select("company_id").Where("status = ?", models.ReconciliationPending)).
+		Pluck("id", &companyIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, companyID := range companyIDs {
+		run, err := ledger.Reconcile(database.DB, companyID, 0)
+		if err != nil {
+			return err
+		}
+		if run.Status != models.ReconciliationPending {
+			continue
+		}
+
+		var admins []models.User
+		if err := database.DB.Where("company_id = ? AND role = ?", companyID, "admin").Find(&admins).Error; err != nil {
+			return err
+		}
+		for _, admin := range admins {
+			err := database.DB.Create(&models.Notification{
+				UserID:     admin.ID,
+				CompanyID:  companyID,
+				Type:       "reconciliation_pending",
+				Message:    fmt.Sprintf("%d items differ from the stock ledger; review reconciliation #%d", len(run.Lines), run.ID),
+				EntityType: ledger.ReferenceReconciliation,
+				EntityID:   run.ID,
+			}).Error
+			if err != nil {
+				log.Printf("Failed to notify admin %d of reconciliation %d: %v", admin.ID, run.ID, err)
+			}
+		}
+	}
+	return nil
+}
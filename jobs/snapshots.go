@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/ledger"
+	"github.com/Twinemukama/go-inventory-manager/models"
+)
+
+// StartStockSnapshots stores every company's closing stock of the previous
+// day, at startup if it is missing and then shortly after each midnight.
+// It only runs when STOCK_SNAPSHOTS=true; without snapshots stock reports
+// replay the ledger.
+func StartStockSnapshots() {
+	if os.Getenv("STOCK_SNAPSHOTS") != "true" {
+		return
+	}
+
+	go func() {
+		for {
+			if err := SnapshotDay(time.Now().AddDate(0, 0, -1)); err != nil {
+				log.Println("Stock snapshot failed:", err)
+			}
+
+			now := time.Now()
+			next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 5, 0, 0, now.Location())
+			time.Sleep(time.Until(next))
+		}
+	}()
+}
+
+// SnapshotDay takes the snapshot of the given day for each company that
+// does not have one yet.
+func SnapshotDay(day time.Time) error {
+	var companyIDs []uint
+	err := database.DB.Model(&models.Company{}).
+		Where("id NOT IN (?)", database.DB.Model(&models.StockSnapshotRun{}).
+			Select("company_id").Where("date = ?", day.Format("2006-01-02"))).
+		Pluck("id", &companyIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range companyIDs {
+		if err := ledger.TakeSnapshot(database.DB, id, day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReferenceReconciliation marks adjustment transactions posted by an
+// approved reconciliation run.
+const ReferenceReconciliation = "reconciliation"
+
+// Mismatches lists a company's items whose cached quantity differs from
+// the sum of their transactions.
+func Mismatches(conn *gorm.DB, companyID uint) ([]models.ReconciliationLine, error) {
+	var lines []models.ReconciliationLine
+	err := conn.Table("items").
+		Select(`items.id AS item_id, items.name, items.sku,
+			items.quantity AS cached_quantity,
+			COALESCE(SUM(`+signedQuantity+`), 0) AS ledger_quantity,
+			items.quantity - COALESCE(SUM(`+signedQuantity+`), 0) AS difference`).
+		Joins("LEFT JOIN transactions ON transactions.item_id = items.id").
+		Where("items.company_id = ? AND items.deleted_at IS NULL", companyID).
+		Group("items.id").
+		Having("items.quantity <> COALESCE(SUM(" + signedQuantity + "), 0)").
+		Order("items.id").
+		Scan(&lines).Error
+	return lines, err
+}
+
+// Reconcile records a run of the company's mismatches. Nothing is
+// corrected until the run is approved.
+func Reconcile(conn *gorm.DB, companyID, userID uint) (*models.ReconciliationRun, error) {
+	lines, err := Mismatches(conn, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	run := models.ReconciliationRun{
+		CompanyID: companyID,
+		Status:    models.ReconciliationPending,
+		UserID:    userID,
+		Lines:     lines,
+	}
+	if len(lines) == 0 {
+		run.Status = models.ReconciliationClean
+	}
+	if err := conn.Create(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Approve posts an adjustment transaction for each line of a pending run
+// so the ledger matches the cached quantity, which is left unchanged. A
+// line whose drift has changed since the run is skipped. afterAdjust, when
+// set, runs in the same transaction after each adjustment is posted.
+func Approve(conn *gorm.DB, run *models.ReconciliationRun, userID uint,
+	afterAdjust func(tx *gorm.DB, item models.Item, t models.Transaction) error) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := lockPending(tx, run); err != nil {
+			return err
+		}
+
+		for i := range run.Lines {
+			line := &run.Lines[i]
+
+			var item models.Item
+			if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&item, "id = ? AND company_id = ?", line.ItemID, run.CompanyID).Error; err != nil {
+				return err
+			}
+			var ledgerQty int
+			if err := tx.Model(&models.Transaction{}).Where("item_id = ?", item.ID).
+				Select("COALESCE(SUM(" + signedQuantity + "), 0)").Scan(&ledgerQty).Error; err != nil {
+				return err
+			}
+
+			if item.Quantity-ledgerQty != line.Difference {
+				line.Skipped = true
+			} else {
+				t := models.Transaction{
+					ItemID:        item.ID,
+					CompanyID:     run.CompanyID,
+					Quantity:      line.Difference,
+					Type:          models.TransactionIn,
+					UnitCost:      item.Cost,
+					Note:          fmt.Sprintf("Reconciliation #%d adjustment", run.ID),
+					UserID:        userID,
+					ReferenceType: ReferenceReconciliation,
+					ReferenceID:   run.ID,
+				}
+				if line.Difference < 0 {
+					t.Type = models.TransactionOut
+					t.Quantity = -line.Difference
+				}
+				if err := tx.Create(&t).Error; err != nil {
+					return err
+				}
+				line.TransactionID = &t.ID
+				if afterAdjust != nil {
+					if err := afterAdjust(tx, item, t); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := tx.Model(line).Updates(map[string]interface{}{
+				"transaction_id": line.TransactionID,
+				"skipped":        line.Skipped,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return review(tx, run, models.ReconciliationApproved, userID)
+	})
+}
+
+// Reject closes a pending run without correcting anything.
+func Reject(conn *gorm.DB, run *models.ReconciliationRun, userID uint) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := lockPending(tx, run); err != nil {
+			return err
+		}
+		return review(tx, run, models.ReconciliationRejected, userID)
+	})
+}
+
+// ErrNotPending is returned when reviewing a run that was already approved,
+// rejected or found clean.
+var ErrNotPending = errors.New("reconciliation run is not pending")
+
+// lockPending locks a run for the rest of the transaction, so it cannot be
+// reviewed twice, and checks it still awaits review.
+func lockPending(tx *gorm.DB, run *models.ReconciliationRun) error {
+	var current models.ReconciliationRun
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, run.ID).Error; err != nil {
+		return err
+	}
+	if current.Status != models.ReconciliationPending {
+		return fmt.Errorf("%w: %s", ErrNotPending, current.Status)
+	}
+	return nil
+}
+
+func review(conn *gorm.DB, run *models.ReconciliationRun, status string, userID uint) error {
+	now := time.Now()
+	run.Status = status
+	run.ReviewedBy = userID
+	run.ReviewedAt = &now
+	return conn.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"reviewed_by": run.ReviewedBy,
+		"reviewed_at": run.ReviewedAt,
+	}).Error
+}
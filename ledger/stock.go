@@ -0,0 +1,120 @@
+// Package ledger derives stock figures from the transaction ledger rather
+// than from the cached Item.Quantity.
+package ledger
+
+import (
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"gorm.io/gorm"
+)
+
+// signedQuantity is a transaction's effect on the quantity on hand.
+const signedQuantity = "CASE WHEN transactions.type = 'OUT' THEN -transactions.quantity ELSE transactions.quantity END"
+
+// StockLine is the stock of one item at a point in time.
+type StockLine struct {
+	ItemID     uint    `json:"item_id"`
+	Name       string  `json:"name"`
+	SKU        string  `json:"sku"`
+	CategoryID uint    `json:"category_id"`
+	Quantity   int     `json:"quantity"`
+	UnitCost   float64 `json:"unit_cost"`
+	Value      float64 `json:"value"`
+}
+
+// StockAsOf reconstructs a company's stock at the given instant by summing
+// its transactions up to then. Each item is valued at its cost as of that
+// instant, taken from its revisions, or its current cost if it has none.
+// Items with nothing on hand are left out, trashed items included since
+// trashing posts their stock out of the ledger.
+func StockAsOf(conn *gorm.DB, companyID uint, asOf time.Time) ([]StockLine, error) {
+	var lines []StockLine
+	err := conn.Table("transactions").
+		Select(`transactions.item_id,
+			COALESCE(items.name, '') AS name,
+			COALESCE(items.sku, '') AS sku,
+			COALESCE(items.category_id, 0) AS category_id,
+			SUM(`+signedQuantity+`) AS quantity,
+			COALESCE((SELECT (r.snapshot->>'cost')::float8 FROM item_revisions r
+				WHERE r.item_id = transactions.item_id AND r.created_at <= ? AND r.snapshot->>'cost' IS NOT NULL
+				ORDER BY r.revision DESC LIMIT 1), MAX(items.cost), 0) AS unit_cost`, asOf).
+		Joins("LEFT JOIN items ON items.id = transactions.item_id").
+		Where("transactions.company_id = ? AND transactions.created_at <= ?", companyID, asOf).
+		Group("transactions.item_id, items.name, items.sku, items.category_id").
+		Having("SUM(" + signedQuantity + ") <> 0").
+		Order("name, transactions.item_id").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].Value = float64(lines[i].Quantity) * lines[i].UnitCost
+	}
+	return lines, nil
+}
+
+// SnapshotAsOf returns the stored snapshot of a company's closing stock on
+// the given day. found is false when no snapshot was taken that day.
+func SnapshotAsOf(conn *gorm.DB, companyID uint, day time.Time) (lines []StockLine, found bool, err error) {
+	var taken int64
+	if err := conn.Model(&models.StockSnapshotRun{}).
+		Where("company_id = ? AND date = ?", companyID, day.Format("2006-01-02")).Count(&taken).Error; err != nil {
+		return nil, false, err
+	}
+	if taken == 0 {
+		return nil, false, nil
+	}
+
+	err = conn.Table("stock_snapshots").
+		Select(`stock_snapshots.item_id,
+			COALESCE(items.name, '') AS name,
+			COALESCE(items.sku, '') AS sku,
+			COALESCE(items.category_id, 0) AS category_id,
+			stock_snapshots.quantity, stock_snapshots.unit_cost, stock_snapshots.value`).
+		Joins("LEFT JOIN items ON items.id = stock_snapshots.item_id").
+		Where("stock_snapshots.company_id = ? AND stock_snapshots.date = ?", companyID, day.Format("2006-01-02")).
+		Order("name, stock_snapshots.item_id").
+		Scan(&lines).Error
+	return lines, true, err
+}
+
+// TakeSnapshot stores a company's closing stock for the given day,
+// replacing any earlier snapshot of that day.
+func TakeSnapshot(conn *gorm.DB, companyID uint, day time.Time) error {
+	date := day.Format("2006-01-02")
+	endOfDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).
+		Add(24*time.Hour - time.Nanosecond)
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		lines, err := StockAsOf(tx, companyID, endOfDay)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("company_id = ? AND date = ?", companyID, date).Delete(&models.StockSnapshot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("company_id = ? AND date = ?", companyID, date).Delete(&models.StockSnapshotRun{}).Error; err != nil {
+			return err
+		}
+
+		rows := make([]models.StockSnapshot, len(lines))
+		for i, l := range lines {
+			rows[i] = models.StockSnapshot{
+				CompanyID: companyID,
+				Date:      date,
+				ItemID:    l.ItemID,
+				Quantity:  l.Quantity,
+				UnitCost:  l.UnitCost,
+				Value:     l.Value,
+			}
+		}
+		if len(rows) > 0 {
+			if err := tx.CreateInBatches(rows, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.StockSnapshotRun{CompanyID: companyID, Date: date, Items: len(rows)}).Error
+	})
+}
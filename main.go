@@ -4,9 +4,12 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/Twinemukama/go-inventory-manager/audit"
 	"github.com/Twinemukama/go-inventory-manager/database"
 	"github.com/Twinemukama/go-inventory-manager/handlers"
+	"github.com/Twinemukama/go-inventory-manager/jobs"
 	"github.com/Twinemukama/go-inventory-manager/middlewares"
+	"github.com/Twinemukama/go-inventory-manager/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,6 +18,15 @@ import (
 func main() {
 	database.InitDB()
 	database.SeedSuperAdmin()
+	audit.InitKey()
+	if err := audit.SealUnhashed(); err != nil {
+		log.Fatal("Failed to seal audit log:", err)
+	}
+	storage.InitStorage()
+	jobs.StartTrashPurge()
+	jobs.StartIdempotencyCleanup()
+	jobs.StartStockSnapshots()
+	jobs.StartReconciliation()
 
 	r := gin.Default()
 
@@ -24,7 +36,8 @@ func main() {
 			"http://localhost:5173",
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 
@@ -42,23 +55,103 @@ func main() {
 	auth.DELETE("/users/:id/reject", handlers.RejectUser)
 
 	//Item routes
-	auth.POST("/items", handlers.CreateItem)
+	auth.POST("/items", middlewares.Idempotency(), handlers.CreateItem)
 	auth.GET("/items", handlers.ListItems)
+	auth.POST("/items/bulk", middlewares.Idempotency(), handlers.BulkItems)
 	auth.GET("/items/:id", handlers.GetItem)
 	auth.PUT("/items/:id", handlers.UpdateItem)
+	auth.PATCH("/items/:id", handlers.PatchItem)
 	auth.DELETE("/items/:id", handlers.DeleteItem)
+	auth.POST("/items/:id/restore", handlers.RestoreItem)
+	auth.GET("/items/:id/stock", handlers.GetItemStock)
+	auth.POST("/items/:id/stock/moves", middlewares.Idempotency(), handlers.MoveItemStock)
+	auth.GET("/items/:id/stock/moves", handlers.ListItemStockMoves)
+	auth.GET("/items/:id/history", handlers.GetItemHistory)
+	auth.POST("/items/:id/revert", middlewares.Idempotency(), handlers.RevertItem)
+
+	//Item image and attachment routes
+	auth.POST("/items/:id/images", middlewares.Idempotency(), handlers.UploadItemImage)
+	auth.POST("/items/:id/attachments", middlewares.Idempotency(), handlers.UploadItemAttachment)
+	auth.GET("/items/:id/attachments", handlers.ListItemAttachments)
+	auth.GET("/attachments/:id/download", handlers.DownloadAttachment)
+	auth.GET("/attachments/:id/thumbnail", handlers.GetAttachmentThumbnail)
+	auth.DELETE("/attachments/:id", handlers.DeleteAttachment)
 
 	//Category routes
-	auth.POST("/categories", handlers.CreateCategory)
+	auth.POST("/categories", middlewares.Idempotency(), handlers.CreateCategory)
 	auth.GET("/categories", handlers.GetCategories)
+	auth.GET("/categories/tree", handlers.GetCategoryTree)
 	auth.GET("/categories/:id", handlers.GetCategory)
 	auth.PUT("/categories/:id", handlers.UpdateCategory)
+	auth.PATCH("/categories/:id", handlers.PatchCategory)
 	auth.DELETE("/categories/:id", handlers.DeleteCategory)
+	auth.PUT("/categories/:id/move", handlers.MoveCategory)
+	auth.POST("/categories/:id/restore", handlers.RestoreCategory)
+	auth.GET("/categories/:id/attributes", handlers.GetCategoryAttributes)
+	auth.POST("/categories/:id/attributes", middlewares.Idempotency(), handlers.CreateCategoryAttribute)
+	auth.DELETE("/categories/:id/attributes/:attrId", handlers.DeleteCategoryAttribute)
+
+	// Trash of soft-deleted items and categories
+	auth.GET("/trash", handlers.GetTrash)
+
+	// SKU generation settings
+	auth.GET("/sku-settings", handlers.GetSKUSettings)
+	auth.PUT("/sku-settings", handlers.UpdateSKUSettings)
+
+	// Tag routes
+	auth.GET("/tags", handlers.GetTags)
+	auth.POST("/tags", middlewares.Idempotency(), handlers.CreateTag)
+	auth.PUT("/tags/:id", handlers.UpdateTag)
+	auth.DELETE("/tags/:id", handlers.DeleteTag)
+	auth.POST("/items/:id/tags", middlewares.Idempotency(), handlers.AddItemTags)
+	auth.DELETE("/items/:id/tags/:tagId", handlers.RemoveItemTag)
+
+	// Transaction routes
+	auth.GET("/transactions", handlers.ListTransactions)
+
+	// Audit log - only admin and super admin can read it
+	auth.GET("/audit", handlers.GetAuditLogs)
+	auth.GET("/audit/verify", handlers.VerifyAuditChain)
+
+	// Customer returns (RMA)
+	auth.POST("/returns", middlewares.Idempotency(), handlers.CreateReturn)
+	auth.GET("/returns", handlers.ListReturns)
+	auth.GET("/returns/:id", handlers.GetReturn)
+	auth.POST("/returns/:id/lines/:lineId/inspect", middlewares.Idempotency(), handlers.InspectReturnLine)
+	auth.POST("/returns/:id/cancel", handlers.CancelReturn)
+
+	auth.GET("/dashboard", handlers.GetDashboard)
+
+	// Reason codes, write-offs and reports
+	auth.GET("/reason-codes", handlers.GetReasonCodes)
+	auth.POST("/reason-codes", middlewares.Idempotency(), handlers.CreateReasonCode)
+	auth.PUT("/reason-codes/:id", handlers.UpdateReasonCode)
+	auth.DELETE("/reason-codes/:id", handlers.DeleteReasonCode)
+	auth.POST("/items/:id/write-offs", middlewares.Idempotency(), handlers.CreateWriteOff)
+	auth.GET("/write-offs", handlers.ListWriteOffs)
+	auth.GET("/reports/shrinkage", handlers.GetShrinkageReport)
+	auth.GET("/reports/stock", handlers.GetStockReport)
+	auth.GET("/reports/movements", handlers.GetMovementReport)
+	auth.POST("/reconciliations", middlewares.Idempotency(), handlers.StartReconciliation)
+	auth.GET("/reconciliations", handlers.ListReconciliations)
+	auth.GET("/reconciliations/:id", handlers.GetReconciliation)
+	auth.POST("/reconciliations/:id/approve", handlers.ApproveReconciliation)
+	auth.POST("/reconciliations/:id/reject", handlers.RejectReconciliation)
+
+	// Backorders and notifications
+	auth.POST("/backorders", middlewares.Idempotency(), handlers.CreateBackorder)
+	auth.GET("/backorders", handlers.ListBackorders)
+	auth.POST("/backorders/:id/cancel", handlers.CancelBackorder)
+	auth.GET("/notifications", handlers.GetNotifications)
+	auth.PUT("/notifications/:id/read", handlers.MarkNotificationRead)
 
 	// Pending Requests routes
 	auth.GET("/pending-requests", handlers.FetchPendingRequests)
 	auth.PATCH("/pending-requests/:id", handlers.RespondToRequest)
 
+	// Batch of sub-requests against the routes above
+	auth.POST("/batch", middlewares.Idempotency(), handlers.Batch(r))
+
 	log.Println("Server is running on :8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
 
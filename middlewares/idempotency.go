@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Twinemukama/go-inventory-manager/database"
+	"github.com/Twinemukama/go-inventory-manager/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodySize bounds the body buffered for hashing, which covers
+// file uploads as well as JSON requests.
+const maxIdempotentBodySize = 32 << 20
+
+// IdempotencyTTL is how long a stored response is replayed for a key. It is
+// read from IDEMPOTENCY_TTL_HOURS and defaults to 24 hours.
+func IdempotencyTTL() time.Duration {
+	if v := os.Getenv("IDEMPOTENCY_TTL_HOURS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Hour
+		}
+	}
+	return 24 * time.Hour
+}
+
+// responseRecorder copies everything written to the client so the response
+// can be stored for replay.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a POST endpoint safe to retry. The first request with a
+// given Idempotency-Key header is processed normally and its response stored
+// per company; later requests with the same key and payload get that
+// response replayed, and requests reusing the key for a different payload
+// are rejected. Requests without the header are not affected.
+// It must run after AuthMiddleware.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		companyID := c.MustGet("companyId").(uint)
+		userID := c.MustGet("userId").(uint)
+
+		record := models.IdempotencyKey{
+			CompanyID:   companyID,
+			Key:         key,
+			UserID:      userID,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestHash,
+		}
+
+		// expired keys may be reused
+		database.DB.Where("company_id = ? AND key = ? AND created_at < ?", companyID, key, time.Now().Add(-IdempotencyTTL())).
+			Delete(&models.IdempotencyKey{})
+
+		res := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if res.Error != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+
+		if res.RowsAffected == 0 {
+			var existing models.IdempotencyKey
+			if err := database.DB.Where("company_id = ? AND key = ?", companyID, key).First(&existing).Error; err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			switch {
+			case existing.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// a panicking handler never finishes the record, which would answer
+		// every retry with "still being processed" until the key expires
+		defer func() {
+			if err := recover(); err != nil {
+				database.DB.Delete(&record)
+				panic(err)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			// server errors are not final, let the client retry with the same key
+			database.DB.Delete(&record)
+			return
+		}
+
+		database.DB.Model(&record).Updates(map[string]interface{}{
+			"status_code":   status,
+			"content_type":  recorder.Header().Get("Content-Type"),
+			"response_body": recorder.body.Bytes(),
+		})
+	}
+}
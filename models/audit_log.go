@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditMove    = "move"
+	AuditRevert  = "revert"
+	AuditUpload  = "upload"
+	AuditVerify  = "verify"
+	AuditReject  = "reject"
+	AuditRespond = "respond"
+	AuditSignup  = "signup"
+	AuditAdjust  = "adjust"
+)
+
+// AuditLog records a single write made through the API, with snapshots of
+// the entity before and after the change. Entries of a company form a hash
+// chain: Hash covers the entry's content and the previous entry's Hash.
+type AuditLog struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	CompanyID  uint                   `json:"company_id" gorm:"index:idx_audit_company_created"`
+	Seq        uint64                 `json:"seq"`
+	ActorID    uint                   `json:"actor_id" gorm:"index"`
+	ActorRole  string                 `json:"actor_role"`
+	Action     string                 `json:"action" gorm:"type:varchar(30);index"`
+	EntityType string                 `json:"entity_type" gorm:"type:varchar(40);index:idx_audit_entity"`
+	EntityID   uint                   `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Before     map[string]interface{} `json:"before" gorm:"type:jsonb;serializer:json"`
+	After      map[string]interface{} `json:"after" gorm:"type:jsonb;serializer:json"`
+	IP         string                 `json:"ip"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"index:idx_audit_company_created"`
+	PrevHash   string                 `json:"prev_hash" gorm:"type:char(64)"`
+	Hash       string                 `json:"hash" gorm:"type:char(64)"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	BackorderOpen      = "open"
+	BackorderFilled    = "filled"
+	BackorderCancelled = "cancelled"
+)
+
+// Backorder is an unfilled quantity of an item waiting for stock. Incoming
+// stock is allocated to open backorders by priority, then age.
+type Backorder struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	CompanyID      uint       `json:"company_id" gorm:"index:idx_backorder_queue"`
+	ItemID         uint       `json:"item_id" gorm:"index:idx_backorder_queue"`
+	OrderReference string     `json:"order_reference"`
+	Quantity       int        `json:"quantity"`
+	Allocated      int        `json:"allocated"`
+	Priority       int        `json:"priority"` // higher is served first
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'open';index:idx_backorder_queue"`
+	UserID         uint       `json:"user_id"` // order owner, notified on allocation
+	FilledAt       *time.Time `json:"filled_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Remaining is the quantity still waiting for stock.
+func (b Backorder) Remaining() int {
+	return b.Quantity - b.Allocated
+}
@@ -1,14 +1,25 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Category struct {
 	ID        uint    `json:"id" gorm:"primaryKey"`
 	Name      string  `json:"name"`
+	Code      string  `json:"code"` // short code used in generated SKUs
+	ParentID  *uint   `json:"parent_id" gorm:"index"`
+	Path      string  `json:"path" gorm:"index"` // ancestor ids including self, e.g. "/1/4/9/"
 	UserID    uint    `json:"user_id"`
 	User      User    `json:"user" gorm:"foreignKey:UserID"`
 	CompanyID uint    `json:"company_id"`
+	Version   int     `json:"version" gorm:"not null;default:1"` // bumped on every update, served as the ETag
 	Company   Company `json:"company" gorm:"foreignKey:CompanyID"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	Children []*Category `json:"children,omitempty" gorm:"-"`
 }
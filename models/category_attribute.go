@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type AttributeType string
+
+const (
+	AttributeText   AttributeType = "text"
+	AttributeNumber AttributeType = "number"
+	AttributeDate   AttributeType = "date"
+	AttributeEnum   AttributeType = "enum"
+)
+
+// CategoryAttribute declares a custom field that items in the category (or
+// any of its subcategories) carry in Item.Attributes.
+type CategoryAttribute struct {
+	ID         uint          `json:"id" gorm:"primaryKey"`
+	CategoryID uint          `json:"category_id" gorm:"uniqueIndex:idx_category_attribute_key"`
+	Key        string        `json:"key" gorm:"not null;uniqueIndex:idx_category_attribute_key"`
+	Label      string        `json:"label"`
+	Type       AttributeType `json:"type" gorm:"type:varchar(20);not null"`
+	Options    []string      `json:"options" gorm:"serializer:json"` // allowed values for enum attributes
+	Required   bool          `json:"required"`
+	// Default is filled in for items without a value. Required attributes
+	// added to a category that already has items need one.
+	Default   interface{} `json:"default" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -1,8 +1,9 @@
 package models
 
 type Company struct {
-	ID    uint   `gorm:"primaryKey"`
-	Name  string `gorm:"unique;not null"`
-	Users []User
-	Items []Item
+	ID             uint   `gorm:"primaryKey"`
+	Name           string `gorm:"unique;not null"`
+	StorageQuotaMB int64  `gorm:"default:500"` // total size allowed for item uploads
+	Users          []User
+	Items          []Item
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the first response to a request sent with an
+// Idempotency-Key header so retries can be answered without repeating it.
+// StatusCode is 0 while the first request is still being processed.
+type IdempotencyKey struct {
+	ID           uint   `gorm:"primaryKey"`
+	CompanyID    uint   `gorm:"uniqueIndex:idx_idempotency_company_key"`
+	Key          string `gorm:"uniqueIndex:idx_idempotency_company_key;size:255"`
+	UserID       uint
+	Method       string `gorm:"size:10"`
+	Path         string
+	RequestHash  string `gorm:"type:char(64)"`
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time `gorm:"index"`
+}
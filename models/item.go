@@ -2,20 +2,28 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Item struct {
-	ID          uint    `json:"id" gorm:"primaryKey"`
-	Name        string  `json:"name"`
-	SKU         string  `json:"sku"`
-	Description string  `json:"description"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	CategoryID  uint    `json:"category_id"`
-	UserID      uint    `json:"user_id"`
-	User        User    `json:"user" gorm:"foreignKey:UserID"`
-	CompanyID   uint    `json:"company_id"`
-	Company     Company `json:"company" gorm:"foreignKey:CompanyID"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID          uint                   `json:"id" gorm:"primaryKey"`
+	Name        string                 `json:"name" gorm:"index"`
+	SKU         string                 `json:"sku" gorm:"index"`
+	Description string                 `json:"description"`
+	Quantity    int                    `json:"quantity" gorm:"index"` // total on hand, whatever its status
+	Available   int                    `json:"available" gorm:"-"`    // sellable part of Quantity, see ItemStock
+	Price       float64                `json:"price" gorm:"index"`
+	Cost        float64                `json:"cost"` // unit cost, used to value stock and write-offs
+	CategoryID  uint                   `json:"category_id" gorm:"index"`
+	Attributes  map[string]interface{} `json:"attributes" gorm:"type:jsonb;serializer:json"`
+	Tags        []Tag                  `json:"tags" gorm:"many2many:item_tags;"`
+	Version     int                    `json:"version" gorm:"not null;default:1"` // bumped on every update, served as the ETag
+	UserID      uint                   `json:"user_id"`
+	User        User                   `json:"user" gorm:"foreignKey:UserID"`
+	CompanyID   uint                   `json:"company_id" gorm:"index"`
+	Company     Company                `json:"company" gorm:"foreignKey:CompanyID"`
+	CreatedAt   time.Time              `gorm:"index"`
+	UpdatedAt   time.Time              `gorm:"index"`
+	DeletedAt   gorm.DeletedAt         `json:"deleted_at,omitempty" gorm:"index"`
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	AttachmentImage    = "image"
+	AttachmentDocument = "attachment"
+)
+
+// ItemAttachment is an image or document uploaded for an item. The file
+// itself lives in storage under StorageKey.
+type ItemAttachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ItemID       uint      `json:"item_id" gorm:"index"`
+	CompanyID    uint      `json:"company_id" gorm:"index"`
+	UserID       uint      `json:"user_id"`
+	Kind         string    `json:"kind" gorm:"type:varchar(20)"` // image or attachment
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	HasThumbnail bool      `json:"has_thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// FieldChange is a single field difference between two item revisions.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ItemRevision is a snapshot of an item's fields after a change, with the
+// field level differences from the previous revision.
+type ItemRevision struct {
+	ID        uint                   `json:"id" gorm:"primaryKey"`
+	ItemID    uint                   `json:"item_id" gorm:"uniqueIndex:idx_item_revision"`
+	Revision  int                    `json:"revision" gorm:"uniqueIndex:idx_item_revision"`
+	CompanyID uint                   `json:"company_id" gorm:"index"`
+	UserID    uint                   `json:"user_id"`
+	Action    string                 `json:"action" gorm:"type:varchar(30)"`
+	Snapshot  map[string]interface{} `json:"snapshot" gorm:"type:jsonb;serializer:json"`
+	Changes   []FieldChange          `json:"changes" gorm:"type:jsonb;serializer:json"`
+	// what caused a change not made by editing the item, e.g. a return
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   uint      `json:"reference_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
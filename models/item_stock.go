@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Stock statuses. Only available stock can be sold or allocated; the rest
+// is on hand but held back.
+const (
+	StockAvailable   = "available"
+	StockReserved    = "reserved"
+	StockDamaged     = "damaged"
+	StockQuarantined = "quarantined"
+)
+
+// HeldStockStatuses are the statuses kept in ItemStock buckets.
+var HeldStockStatuses = []string{StockReserved, StockDamaged, StockQuarantined}
+
+// ItemStock is the quantity of an item held in a non-sellable status.
+// Item.Quantity remains the total on hand, so available stock is the item
+// quantity minus its buckets.
+type ItemStock struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CompanyID uint      `json:"company_id" gorm:"index"`
+	ItemID    uint      `json:"item_id" gorm:"uniqueIndex:idx_item_stock_status"`
+	Status    string    `json:"status" gorm:"type:varchar(20);uniqueIndex:idx_item_stock_status"`
+	Quantity  int       `json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockStatusMove records quantity shifted between statuses of an item.
+// It does not change the quantity on hand and so is not a Transaction.
+type StockStatusMove struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CompanyID  uint      `json:"company_id" gorm:"index"`
+	ItemID     uint      `json:"item_id" gorm:"index"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(20)"`
+	Quantity   int       `json:"quantity"`
+	Note       string    `json:"note"`
+	UserID     uint      `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
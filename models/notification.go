@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Notification is a message for a single user, such as stock being
+// allocated to one of their backorders.
+type Notification struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	CompanyID  uint       `json:"company_id"`
+	Type       string     `json:"type" gorm:"type:varchar(40)"`
+	Message    string     `json:"message"`
+	EntityType string     `json:"entity_type"`
+	EntityID   uint       `json:"entity_id"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ReasonCodeScrap is the code of the reason code that scrapped return lines
+// are written off with. It is created per company when first needed.
+const ReasonCodeScrap = "scrap"
+
+// ReasonCode is a company-defined reason for writing stock off, such as
+// breakage, theft or expiry.
+type ReasonCode struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CompanyID   uint      `json:"company_id" gorm:"uniqueIndex:idx_reason_code_company_code"`
+	Code        string    `json:"code" gorm:"not null;uniqueIndex:idx_reason_code_company_code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active" gorm:"not null;default:true"` // inactive codes are kept for history but cannot be used
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WriteOff removes stock from the books for a reason. Its OUT transaction
+// carries the quantity; the write-off keeps the reason and the value lost.
+type WriteOff struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CompanyID     uint       `json:"company_id" gorm:"index:idx_write_off_company_created"`
+	ItemID        uint       `json:"item_id" gorm:"index"`
+	CategoryID    uint       `json:"category_id"` // item category at the time of the write-off
+	ReasonCodeID  uint       `json:"reason_code_id" gorm:"index"`
+	ReasonCode    ReasonCode `json:"reason_code" gorm:"foreignKey:ReasonCodeID"`
+	Status        string     `json:"status"` // stock status written off from
+	Quantity      int        `json:"quantity"`
+	UnitCost      float64    `json:"unit_cost"`
+	Value         float64    `json:"value"`
+	Note          string     `json:"note"`
+	TransactionID uint       `json:"transaction_id"`
+	UserID        uint       `json:"user_id"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index:idx_write_off_company_created"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	ReconciliationPending  = "pending"
+	ReconciliationApproved = "approved"
+	ReconciliationRejected = "rejected"
+	ReconciliationClean    = "clean" // no mismatches found
+)
+
+// ReconciliationRun compares each item's cached quantity with the sum of
+// its transactions. Mismatches are corrected only once an admin approves.
+type ReconciliationRun struct {
+	ID         uint                 `json:"id" gorm:"primaryKey"`
+	CompanyID  uint                 `json:"company_id" gorm:"index"`
+	Status     string               `json:"status" gorm:"type:varchar(20)"`
+	UserID     uint                 `json:"user_id"` // 0 when started by the nightly job
+	ReviewedBy uint                 `json:"reviewed_by"`
+	ReviewedAt *time.Time           `json:"reviewed_at"`
+	Lines      []ReconciliationLine `json:"lines" gorm:"foreignKey:RunID"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// ReconciliationLine is one item whose cached quantity differs from its
+// ledger. Difference is cached minus ledger.
+type ReconciliationLine struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	RunID          uint   `json:"run_id" gorm:"index"`
+	ItemID         uint   `json:"item_id"`
+	Name           string `json:"name"`
+	SKU            string `json:"sku"`
+	CachedQuantity int    `json:"cached_quantity"`
+	LedgerQuantity int    `json:"ledger_quantity"`
+	Difference     int    `json:"difference"`
+	TransactionID  *uint  `json:"transaction_id"` // adjustment posted on approval
+	Skipped        bool   `json:"skipped"`        // drift changed after the run, left for the next one
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	ReturnOpen      = "open"
+	ReturnCompleted = "completed"
+	ReturnCancelled = "cancelled"
+)
+
+// Dispositions decided when a returned line is inspected.
+const (
+	DispositionRestock    = "restock"
+	DispositionQuarantine = "quarantine"
+	DispositionScrap      = "scrap"
+)
+
+// ReturnAuthorization (RMA) tracks goods a customer sends back. There is no
+// sales order model yet, so the originating order is kept as a reference.
+type ReturnAuthorization struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	CompanyID      uint         `json:"company_id" gorm:"index"`
+	Number         string       `json:"number" gorm:"index"`
+	OrderReference string       `json:"order_reference"`
+	CustomerName   string       `json:"customer_name"`
+	Reason         string       `json:"reason"`
+	Status         string       `json:"status" gorm:"type:varchar(20);default:'open'"`
+	UserID         uint         `json:"user_id"`
+	RefundTotal    float64      `json:"refund_total"`
+	CreditTotal    float64      `json:"credit_total"`
+	Lines          []ReturnLine `json:"lines" gorm:"foreignKey:ReturnAuthorizationID"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// ReturnLine is one returned item on an RMA. Disposition stays empty until
+// the goods have been inspected.
+type ReturnLine struct {
+	ID                    uint       `json:"id" gorm:"primaryKey"`
+	ReturnAuthorizationID uint       `json:"return_authorization_id" gorm:"index"`
+	ItemID                uint       `json:"item_id" gorm:"index"`
+	Quantity              int        `json:"quantity"`
+	Disposition           string     `json:"disposition" gorm:"type:varchar(20)"`
+	InspectionNote        string     `json:"inspection_note"`
+	RefundAmount          float64    `json:"refund_amount"`
+	CreditAmount          float64    `json:"credit_amount"`
+	TransactionID         *uint      `json:"transaction_id"` // IN transaction posted when the line was inspected
+	InspectedBy           uint       `json:"inspected_by"`
+	InspectedAt           *time.Time `json:"inspected_at"`
+}
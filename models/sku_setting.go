@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SKUSetting configures how SKUs are generated for a company's items when
+// none is supplied, e.g. "ITM-ELEC-00042".
+type SKUSetting struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	CompanyID       uint   `json:"company_id" gorm:"uniqueIndex"`
+	Prefix          string `json:"prefix"`
+	IncludeCategory bool   `json:"include_category"`
+	Separator       string `json:"separator"`
+	Padding         int    `json:"padding"`
+	NextSequence    int64  `json:"next_sequence"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
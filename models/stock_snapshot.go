@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// StockSnapshot is an item's closing stock on a day, stored nightly so
+// historical stock reports need not replay the whole ledger.
+type StockSnapshot struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	CompanyID uint    `json:"company_id" gorm:"index:idx_stock_snapshot_company_date"`
+	Date      string  `json:"date" gorm:"type:date;index:idx_stock_snapshot_company_date;uniqueIndex:idx_stock_snapshot_item_date"`
+	ItemID    uint    `json:"item_id" gorm:"uniqueIndex:idx_stock_snapshot_item_date"`
+	Quantity  int     `json:"quantity"`
+	UnitCost  float64 `json:"unit_cost"`
+	Value     float64 `json:"value"`
+}
+
+// StockSnapshotRun marks a company's snapshot of a day as complete, so a
+// day with no stock is told apart from a day never snapshotted.
+type StockSnapshotRun struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CompanyID uint      `json:"company_id" gorm:"uniqueIndex:idx_stock_snapshot_run"`
+	Date      string    `json:"date" gorm:"type:date;uniqueIndex:idx_stock_snapshot_run"`
+	Items     int       `json:"items"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Name      string `json:"name" gorm:"not null;uniqueIndex:idx_tag_company_name"`
+	Color     string `json:"color"`
+	CompanyID uint   `json:"company_id" gorm:"uniqueIndex:idx_tag_company_name"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
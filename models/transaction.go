@@ -9,12 +9,27 @@ const (
 	TransactionOut TransactionType = "OUT"
 )
 
+// References of transactions posted for quantities written directly to an
+// item rather than moved through a stock endpoint, so the ledger always
+// sums to Item.Quantity.
+const (
+	ReferenceOpeningBalance = "opening_balance" // quantity an item was created with
+	ReferenceItemEdit       = "item_edit"       // quantity set through PUT or PATCH
+	ReferenceItemTrash      = "item_trash"      // stock leaving the books when an item is trashed
+	ReferenceItemRestore    = "item_restore"    // stock returning when an item is restored
+)
+
 type Transaction struct {
-	ID        uint            `json:"id" gorm:"primaryKey"`
-	ItemID    uint            `json:"item_id"`
-	Quantity  int             `json:"quantity"`
-	Type      TransactionType `json:"type"` // IN or OUT
-	Note      string          `json:"note"`
-	UserID    uint            `json:"user_id"`
-	CreatedAt time.Time
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	ItemID        uint            `json:"item_id" gorm:"index"`
+	CompanyID     uint            `json:"company_id" gorm:"index"`
+	Quantity      int             `json:"quantity"`
+	Type          TransactionType `json:"type"`                                     // IN or OUT
+	Status        string          `json:"status,omitempty" gorm:"type:varchar(20)"` // stock status moved in or out; empty means available
+	UnitCost      float64         `json:"unit_cost"`                                // item cost when the movement was posted
+	Note          string          `json:"note"`
+	UserID        uint            `json:"user_id"`
+	ReferenceType string          `json:"reference_type,omitempty"` // what caused the movement, e.g. "return"
+	ReferenceID   uint            `json:"reference_id,omitempty"`
+	CreatedAt     time.Time       `gorm:"index"`
 }
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+)
+
+// ErrNotFound is returned when a key does not exist in the store.
+var ErrNotFound = errors.New("file not found")
+
+// Storage persists uploaded files under opaque keys.
+type Storage interface {
+	Save(key string, r io.Reader) (int64, error)
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+var Files Storage
+
+// InitStorage sets up the storage backend used for uploads.
+func InitStorage() {
+	root := os.Getenv("UPLOAD_DIR")
+	if root == "" {
+		root = "uploads"
+	}
+
+	local, err := NewLocal(root)
+	if err != nil {
+		log.Fatal("Failed to initialise file storage:", err)
+	}
+	Files = local
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// ThumbnailSize is the longest edge, in pixels, of generated thumbnails.
+const ThumbnailSize = 200
+
+// MaxImagePixels caps the size of images Thumbnail will decode. Decoding
+// allocates the whole bitmap, so the limit is checked against the
+// dimensions in the image header first.
+const MaxImagePixels = 25_000_000
+
+// ErrImageTooLarge is returned for images larger than MaxImagePixels.
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+// Thumbnail decodes a JPEG, PNG or GIF image and returns a JPEG scaled down so
+// its longest edge is at most ThumbnailSize. Smaller images are re-encoded
+// at their original size.
+func Thumbnail(data []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := thumbnailSize(src.Bounds().Dx(), src.Bounds().Dy())
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnailSize fits w×h within ThumbnailSize, keeping the aspect ratio.
+func thumbnailSize(w, h int) (int, int) {
+	if w <= ThumbnailSize && h <= ThumbnailSize {
+		return w, h
+	}
+	if w >= h {
+		return ThumbnailSize, max(1, h*ThumbnailSize/w)
+	}
+	return max(1, w*ThumbnailSize/h), ThumbnailSize
+}
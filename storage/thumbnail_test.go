@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// gifHeader returns just a GIF header and logical screen descriptor, which is
+// all DecodeConfig needs to report the dimensions.
+func gifHeader(w, h uint16) []byte {
+	return []byte{'G', 'I', 'F', '8', '9', 'a', byte(w), byte(w >> 8), byte(h), byte(h >> 8), 0, 0, 0}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantW   int
+		wantH   int
+		wantErr error
+	}{
+		{name: "small image keeps size", data: encodePNG(t, 40, 30), wantW: 40, wantH: 30},
+		{name: "wide image", data: encodePNG(t, 400, 100), wantW: 200, wantH: 50},
+		{name: "tall image", data: encodePNG(t, 100, 400), wantW: 50, wantH: 200},
+		{name: "too many pixels", data: gifHeader(6000, 6000), wantErr: ErrImageTooLarge},
+		{name: "zero width", data: gifHeader(0, 10), wantErr: ErrImageTooLarge},
+		{name: "not an image", data: []byte("hello"), wantErr: image.ErrFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Thumbnail(tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != "jpeg" || cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("got %s %dx%d, want jpeg %dx%d", format, cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		w, h, wantW, wantH int
+	}{
+		{200, 200, 200, 200},
+		{201, 1, 200, 1},
+		{1, 5000, 1, 200},
+		{1000, 500, 200, 100},
+	}
+	for _, tt := range tests {
+		w, h := thumbnailSize(tt.w, tt.h)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("thumbnailSize(%d, %d) = %d, %d, want %d, %d", tt.w, tt.h, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}